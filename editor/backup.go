@@ -0,0 +1,110 @@
+package editor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// backup file
+//
+// Before the file is overwritten by Save the previous version is
+// kept as "file~" next to it, or, if backup directory is configured, as
+// numbered versions "dir/<escaped path>.~N~" of which only the last
+// Options.Backup.Keep are retained. "file~" is written only on the first
+// save of the file in session, so it keeps the version before editing.
+
+// backupFileName returns the name of backup in directory dir with number n.
+func backupFileName(dir, filename string, n int) string {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		abs = filename
+	}
+	escaped := strings.Replace(abs, string(filepath.Separator), "%", -1)
+	return filepath.Join(dir, fmt.Sprintf("%s.~%d~", escaped, n))
+}
+
+// backupVersions returns the sorted numbers of existed backups of filename
+// in directory dir.
+func backupVersions(dir, filename string) (versions []int, err error) {
+	prefix := strings.TrimSuffix(filepath.Base(backupFileName(dir, filename, 0)), "0~")
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, "~") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, prefix), "~"))
+		if err != nil {
+			continue
+		}
+		versions = append(versions, n)
+	}
+	sort.Ints(versions)
+	return
+}
+
+// editorBackup keeps the current content of filename before it is
+// overwritten. Not existed file is not a error.
+func (e *Editor) editorBackup(filename string) error {
+	if !e.options.Backup.Enable {
+		return nil
+	}
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var mode os.FileMode = 0644
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := e.options.Backup.Dir
+	if dir == "" {
+		if e.backup == filename {
+			// backup of session is written
+			return nil
+		}
+		if err = ioutil.WriteFile(filename+"~", content, mode); err != nil {
+			return err
+		}
+		e.backup = filename
+		return nil
+	}
+
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	versions, err := backupVersions(dir, filename)
+	if err != nil {
+		return err
+	}
+	next := 1
+	if len(versions) > 0 {
+		next = versions[len(versions)-1] + 1
+	}
+	if err = ioutil.WriteFile(backupFileName(dir, filename, next), content, mode); err != nil {
+		return err
+	}
+	versions = append(versions, next)
+
+	// remove old versions
+	if keep := e.options.Backup.Keep; keep > 0 && len(versions) > keep {
+		for _, n := range versions[:len(versions)-keep] {
+			if err = os.Remove(backupFileName(dir, filename, n)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
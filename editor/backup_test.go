@@ -0,0 +1,72 @@
+package editor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "file.txt")
+	backups := filepath.Join(dir, "backups")
+
+	var options Options
+	options.Backup.Enable = true
+	options.Backup.Dir = backups
+	options.Backup.Keep = 2
+	e, err := New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, content := range []string{"1", "2", "3", "4"} {
+		if err := e.editorBackup(filename); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := backupVersions(backups, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0] != 2 || versions[1] != 3 {
+		t.Fatalf("unexpected versions: %v", versions)
+	}
+	b, err := ioutil.ReadFile(backupFileName(backups, filename, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "3" {
+		t.Fatalf("unexpected content of last backup: %q", b)
+	}
+
+	// backup near the file
+	e.options.Backup.Dir = ""
+	if err := e.editorBackup(filename); err != nil {
+		t.Fatal(err)
+	}
+	if b, err = ioutil.ReadFile(filename + "~"); err != nil || string(b) != "4" {
+		t.Fatalf("unexpected backup: %q %v", b, err)
+	}
+
+	// backup of the first save is kept
+	if err := ioutil.WriteFile(filename, []byte("5"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.editorBackup(filename); err != nil {
+		t.Fatal(err)
+	}
+	if b, err = ioutil.ReadFile(filename + "~"); err != nil || string(b) != "4" {
+		t.Fatalf("backup is overwritten: %q %v", b, err)
+	}
+}
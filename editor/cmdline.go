@@ -0,0 +1,327 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// command line
+//
+// Command line runs the command by name with arguments, like ":save
+// main.go", ":edit main.go" or ":set tabstop=8". Commands are filtered by
+// letters of typed name in the same order. Enter runs the command of the
+// name or the only command starting with the name. Tab completes the name
+// of command or the last argument, repeated Tab shows the next completion.
+// ArrowUp and ArrowDown show previous lines of command line. Names "w", "e"
+// and "q" are short names of commands "save", "edit" and "quit". Command
+// line is bound to Ctrl-P, config file binds it to ":" by option
+// "key : = command-line".
+
+// commandAliases are short names of commands
+var commandAliases = map[string]string{
+	"w": "save",
+	"e": "edit",
+	"q": "quit",
+}
+
+// argumentCompletions return completions of the last argument of commands
+var argumentCompletions = map[string]func(arg string) []string{
+	"save": completeFile,
+	"edit": completeFile,
+	"set":  completeOption,
+}
+
+// fuzzyScore returns the score of name for pattern, if letters of pattern
+// are in name in the same order. Less score is better: prefix is the best,
+// then the earlier and the closer letters.
+func fuzzyScore(pattern, name string) (score int, ok bool) {
+	if strings.HasPrefix(name, pattern) {
+		return 0, true
+	}
+	first, last, j := -1, -1, 0
+	for i := 0; i < len(name) && j < len(pattern); i++ {
+		if name[i] == pattern[j] {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			j++
+		}
+	}
+	if j < len(pattern) {
+		return 0, false
+	}
+	return 1 + first + (last - first + 1 - len(pattern)), true
+}
+
+// matchCommands returns names of commands matched by pattern. The best
+// match is the first.
+func matchCommands(pattern string) []string {
+	scores := map[string]int{}
+	var names []string
+	for _, name := range commandNames() {
+		if score, ok := fuzzyScore(pattern, name); ok {
+			scores[name] = score
+			names = append(names, name)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return scores[names[i]] < scores[names[j]]
+	})
+	return names
+}
+
+// resolveCommand returns the command of name, of short name or the only
+// command starting with name. Other fuzzy matches are not run, because a
+// typo may run the unrelated command.
+func resolveCommand(name string) (command, bool) {
+	if alias, ok := commandAliases[name]; ok {
+		name = alias
+	}
+	if c, ok := commands[name]; ok {
+		return c, true
+	}
+	var found []string
+	for _, n := range commandNames() {
+		if strings.HasPrefix(n, name) {
+			found = append(found, n)
+		}
+	}
+	if len(found) == 1 {
+		return commands[found[0]], true
+	}
+	return command{}, false
+}
+
+// completeFile returns names of files, that start with arg. Names of
+// directories end with separator.
+func completeFile(arg string) (names []string) {
+	dir, prefix := filepath.Split(arg)
+	read := dir
+	if read == "" {
+		read = "."
+	}
+	files, err := ioutil.ReadDir(read)
+	if err != nil {
+		return nil
+	}
+	for _, f := range files {
+		name := f.Name()
+		if !strings.HasPrefix(name, prefix) || strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if f.IsDir() {
+			name += string(filepath.Separator)
+		}
+		names = append(names, dir+name)
+	}
+	return
+}
+
+// completeOption returns options of config file, that start with arg, and
+// names of themes for option "theme".
+func completeOption(arg string) (names []string) {
+	options := []string{"tabstop=", "quit-times=", "message-timeout=", "theme="}
+	for _, g := range highlightGroups {
+		options = append(options, "color."+g+"=")
+	}
+	for _, name := range ThemeNames() {
+		options = append(options, "theme="+name)
+	}
+	for _, o := range options {
+		if strings.HasPrefix(o, arg) && o != arg {
+			names = append(names, o)
+		}
+	}
+	return
+}
+
+// completeCommandLine returns completions of the command line: names of
+// commands or completions of the last argument. Completion replaces the
+// line after base.
+func completeCommandLine(line string) (base string, completions []string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || len(fields) == 1 && !strings.HasSuffix(line, " ") {
+		pattern := strings.TrimSpace(line)
+		for _, name := range matchCommands(pattern) {
+			completions = append(completions, name+" ")
+		}
+		return line[:len(line)-len(strings.TrimLeft(line, " "))], completions
+	}
+	cmd, ok := resolveCommand(fields[0])
+	complete := argumentCompletions[cmd.name]
+	if !ok || complete == nil {
+		return line, nil
+	}
+	arg := ""
+	if !strings.HasSuffix(line, " ") {
+		arg = fields[len(fields)-1]
+	}
+	return line[:len(line)-len(arg)], complete(arg)
+}
+
+// commandLineHint returns the hint of command line: matched commands or
+// usage of command.
+func commandLineHint(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || len(fields) == 1 && !strings.HasSuffix(line, " ") {
+		names := matchCommands(strings.TrimSpace(line))
+		if len(names) == 0 {
+			return "  (unknown command)"
+		}
+		return "  " + strings.Join(names, " | ")
+	}
+	cmd, ok := resolveCommand(fields[0])
+	if !ok {
+		return "  (unknown command)"
+	}
+	if cmd.args == "" {
+		return fmt.Sprintf("  %s: %s", cmd.name, cmd.help)
+	}
+	return fmt.Sprintf("  %s %s: %s", cmd.name, cmd.args, cmd.help)
+}
+
+// editorCommandLine reads the command line and runs its command.
+func (e *Editor) editorCommandLine() error {
+	pos := len(e.history) // shown line of history
+	var typed []byte      // line before moving in history
+	var completions []string
+	var base string
+	next := 0
+	line, err := e.editorPrompt(":%s", func(buf []byte, k Key) ([]byte, string) {
+		if k.Code != '\t' {
+			completions = nil
+		}
+		switch k.Code {
+		case '\t':
+			if completions == nil {
+				base, completions = completeCommandLine(string(buf))
+				next = 0
+			}
+			if len(completions) > 0 {
+				buf = []byte(base + completions[next%len(completions)])
+				next++
+			}
+		case ARROW_UP:
+			if pos == len(e.history) {
+				typed = buf
+			}
+			if pos > 0 {
+				pos--
+				buf = []byte(e.history[pos])
+			}
+		case ARROW_DOWN:
+			if pos < len(e.history) {
+				pos++
+				if pos == len(e.history) {
+					buf = typed
+				} else {
+					buf = []byte(e.history[pos])
+				}
+			}
+		}
+		return buf, commandLineHint(string(buf))
+	})
+	if err != nil || line == "" {
+		return err
+	}
+	if n := len(e.history); n == 0 || e.history[n-1] != line {
+		e.history = append(e.history, line)
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	cmd, ok := resolveCommand(fields[0])
+	if !ok {
+		e.editorSetStatusMessage("Unknown command %q", fields[0])
+		return nil
+	}
+	if e.hex.enable && !hexCommands[cmd.name] {
+		e.editorSetStatusMessage("Command %s is not available in hex view", cmd.name)
+		return nil
+	}
+	if cmd.args == "" && len(fields) > 1 {
+		e.editorSetStatusMessage("Command %s has no arguments", cmd.name)
+		return nil
+	}
+	return e.editorRunCommand(cmd, ":"+fields[0], fields[1:])
+}
+
+// editorSaveAs saves the buffer to file of argument or to its file.
+func (e *Editor) editorSaveAs(args []string) error {
+	if len(args) > 1 {
+		e.editorSetStatusMessage("Usage: save [file]")
+		return nil
+	}
+	if len(args) == 1 && args[0] != e.filename {
+		e.filename = args[0]
+		if err := e.editorLoadConfig(e.filename); err != nil {
+			e.editorSetStatusMessage("%v", err)
+		} else if err := e.editorUpdateTheme(); err != nil {
+			return err
+		}
+	}
+	return e.Save()
+}
+
+// editorEdit opens the file of argument instead of the buffer without
+// changes. Not existing file is empty buffer.
+func (e *Editor) editorEdit(args []string) error {
+	if len(args) != 1 {
+		e.editorSetStatusMessage("Usage: edit <file>")
+		return nil
+	}
+	if e.dirty {
+		e.editorSetStatusMessage("File has unsaved changes. Save it before edit of other file.")
+		return nil
+	}
+	e.editorRemoveSwap()
+	err := e.Open(args[0])
+	if err != nil {
+		// empty buffer of new file
+		e.Load(bytes.NewReader(nil))
+	}
+	switch {
+	case os.IsNotExist(err):
+		e.editorSetStatusMessage("New file %s", e.filename)
+	case err != nil:
+		e.filename = ""
+		e.editorSetStatusMessage("%v", err)
+	default:
+		e.editorSetStatusMessage("%s opened", e.filename)
+	}
+	e.editorInvalidateScreen()
+	if err := e.editorUpdateTheme(); err != nil {
+		return err
+	}
+	return e.editorCheckSwap()
+}
+
+// editorSet sets the options of config file for the buffer.
+func (e *Editor) editorSet(args []string) error {
+	if len(args) == 0 {
+		e.editorSetStatusMessage("Usage: set <option>=<value> ...")
+		return nil
+	}
+	s := e.settings
+	for _, arg := range args {
+		eq := strings.IndexByte(arg, '=')
+		if eq < 0 {
+			e.editorSetStatusMessage("set: expected <option>=<value>, got %q", arg)
+			return nil
+		}
+		if err := s.set(configOption{pos: "set", name: arg[:eq], value: arg[eq+1:]}); err != nil {
+			e.editorSetStatusMessage("%v", err)
+			return nil
+		}
+	}
+	e.editorApplySettings(s)
+	return e.editorUpdateTheme()
+}
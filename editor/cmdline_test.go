@@ -0,0 +1,135 @@
+package editor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchCommands(t *testing.T) {
+	tcs := []struct {
+		pattern string
+		names   []string
+	}{
+		{"sav", []string{"save", "save-encoding"}},
+		{"tgh", []string{"toggle-hex"}},
+		{"mvl", []string{"move-left", "move-word-left"}},
+		{"xyz", nil},
+	}
+	for _, tc := range tcs {
+		if names := matchCommands(tc.pattern); !reflect.DeepEqual(names, tc.names) {
+			t.Errorf("%s: got %v, expected %v", tc.pattern, names, tc.names)
+		}
+	}
+	for name, expect := range map[string]string{"w": "save", "q": "quit", "set": "set", "sus": "suspend", "x": "", "d": "", "sav": ""} {
+		if c, ok := resolveCommand(name); ok != (expect != "") || c.name != expect {
+			t.Errorf("%s: got %q, expected %q", name, c.name, expect)
+		}
+	}
+}
+
+func TestCompleteFile(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{"abc.go", "abd/", ".abe", "x.go"} {
+		var err error
+		if strings.HasSuffix(name, "/") {
+			err = os.Mkdir(filepath.Join(dir, name), 0755)
+		} else {
+			err = ioutil.WriteFile(filepath.Join(dir, name), nil, 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tcs := []struct {
+		arg   string
+		names []string
+	}{
+		{dir + "/ab", []string{dir + "/abc.go", dir + "/abd/"}},
+		{dir + "/.a", []string{dir + "/.abe"}},
+		{dir + "/abd/", nil},
+	}
+	for _, tc := range tcs {
+		if names := completeFile(tc.arg); !reflect.DeepEqual(names, tc.names) {
+			t.Errorf("%s: got %v, expected %v", tc.arg, names, tc.names)
+		}
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	other := filepath.Join(dir, "other.txt")
+	if err := ioutil.WriteFile(other, []byte("first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestEditor(t)
+	e.editorInsertRow(0, []byte("\tx"))
+	e.filename = filepath.Join(dir, "a.txt")
+	run := func(msg string, names ...string) {
+		t.Helper()
+		var keys []Key
+		for _, name := range append([]string{"Ctrl-P"}, names...) {
+			if len(name) == 1 {
+				keys = append(keys, Key{Code: int(name[0])})
+				continue
+			}
+			k, err := parseKeyName(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys = append(keys, k)
+		}
+		e.term = &Mock{line: keys}
+		if _, err := e.ProcessKeypress(); err != nil {
+			t.Fatal(err)
+		}
+		if e.status.msg != msg {
+			t.Errorf("got message %q, expected %q", e.status.msg, msg)
+		}
+	}
+	text := func(s string) (names []string) {
+		for _, c := range s {
+			names = append(names, string(c))
+		}
+		return
+	}
+
+	// arguments and completion
+	run("", append(text("set tab"), "Tab", "8", "Enter")...)
+	if e.settings.tabStop != 8 || e.rows[0].rsize != 9 {
+		t.Errorf("unexpected tab stop %d", e.settings.tabStop)
+	}
+	run("Command quit has no arguments", append(text("q now"), "Enter")...)
+	run("Unknown command \"xyz\"", append(text("xyz"), "Enter")...)
+	run(`set: unknown option "foo"`, append(text("set foo=1"), "Enter")...)
+
+	// history
+	run("", "ArrowUp", "ArrowUp", "ArrowUp", "ArrowUp", "ArrowDown", "ArrowUp", "Backspace", "4", "Enter")
+	if e.settings.tabStop != 4 {
+		t.Errorf("unexpected tab stop %d", e.settings.tabStop)
+	}
+	if expect := []string{"set tabstop=8", "q now", "xyz", "set foo=1", "set tabstop=4"}; !reflect.DeepEqual(e.history, expect) {
+		t.Errorf("unexpected history %q", e.history)
+	}
+
+	// save as and edit
+	run("File has unsaved changes. Save it before edit of other file.", append(text("e "+other), "Enter")...)
+	run("3 bytes written to disk", append(text("w "+filepath.Join(dir, "b.txt")), "Enter")...)
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "b.txt")); err != nil || string(b) != "\tx\n" {
+		t.Errorf("unexpected saved file %q %v", b, err)
+	}
+	run(other+" opened", append(text("e "+other), "Enter")...)
+	if got := string(e.rows[0].chars); got != "first" || e.filename != other {
+		t.Errorf("unexpected buffer %q of %s", got, e.filename)
+	}
+}
@@ -0,0 +1,158 @@
+package editor
+
+import (
+	"errors"
+	"sort"
+)
+
+// commands
+//
+// Commands are the named actions of editor. Keys are bound to commands by
+// keymap, see keymap.
+
+// command is the named action of editor. Arguments are given only by
+// command line, see cmdline.
+type command struct {
+	name string
+	help string
+	args string // usage of arguments, empty if command has no arguments
+	run  func(e *Editor, args []string) error
+}
+
+// errQuit is returned by command "quit" for quit of editor.
+var errQuit = errors.New("quit")
+
+// commands are registered commands by name
+var commands = map[string]command{}
+
+// commandNames returns sorted names of all registered commands.
+func commandNames() (names []string) {
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+func init() {
+	for _, c := range []command{
+		{"none", "Do nothing.", "", func(e *Editor, _ []string) error { return nil }},
+		{"newline", "Split the line at cursor.", "", func(e *Editor, _ []string) error { e.InsertNewLine(); return nil }},
+		{"indent", "Insert tab or spaces of indent.", "", func(e *Editor, _ []string) error { e.editorInsertTab(); return nil }},
+		{"delete-backward", "Delete character before cursor.", "", func(e *Editor, _ []string) error { e.DelChar(); return nil }},
+		{"delete-forward", "Delete character at cursor.", "", func(e *Editor, _ []string) error {
+			e.editorMoveCursor(ARROW_RIGHT)
+			e.DelChar()
+			return nil
+		}},
+		{"move-left", "Move cursor left.", "", func(e *Editor, _ []string) error { e.editorMoveCursor(ARROW_LEFT); return nil }},
+		{"move-right", "Move cursor right.", "", func(e *Editor, _ []string) error { e.editorMoveCursor(ARROW_RIGHT); return nil }},
+		{"move-up", "Move cursor up.", "", func(e *Editor, _ []string) error { e.editorMoveCursor(ARROW_UP); return nil }},
+		{"move-down", "Move cursor down.", "", func(e *Editor, _ []string) error { e.editorMoveCursor(ARROW_DOWN); return nil }},
+		{"move-word-left", "Move cursor to start of word.", "", func(e *Editor, _ []string) error { e.editorMoveWord(ARROW_LEFT); return nil }},
+		{"move-word-right", "Move cursor to start of next word.", "", func(e *Editor, _ []string) error { e.editorMoveWord(ARROW_RIGHT); return nil }},
+		{"line-start", "Move cursor to start of line.", "", func(e *Editor, _ []string) error { e.cursor.x = 0; return nil }},
+		{"line-end", "Move cursor to end of line.", "", func(e *Editor, _ []string) error {
+			if e.cursor.y < len(e.rows) {
+				e.cursor.x = e.rows[e.cursor.y].size
+			}
+			return nil
+		}},
+		{"page-up", "Move cursor one screen up.", "", func(e *Editor, _ []string) error { e.editorPageMove(ARROW_UP); return nil }},
+		{"page-down", "Move cursor one screen down.", "", func(e *Editor, _ []string) error { e.editorPageMove(ARROW_DOWN); return nil }},
+		{"save", "Save the buffer to file.", "[file]", (*Editor).editorSaveAs},
+		{"save-encoding", "Save the buffer with other encoding.", "", func(e *Editor, _ []string) error { return e.editorSaveEncoding() }},
+		{"quit", "Quit, unsaved changes are confirmed by repeat.", "", func(e *Editor, _ []string) error { return e.editorQuit() }},
+		{"toggle-hex", "Switch between text and hex view.", "", func(e *Editor, _ []string) error { e.editorToggleHex(); return nil }},
+		{"toggle-mouse", "Enable or disable mouse.", "", func(e *Editor, _ []string) error { e.editorToggleMouse(); return nil }},
+		{"suspend", "Suspend the editor.", "", func(e *Editor, _ []string) error { return e.editorSuspend() }},
+		{"redraw", "Redraw the screen.", "", func(e *Editor, _ []string) error { e.editorInvalidateScreen(); return nil }},
+		{"describe-key", "Show the command of key.", "", func(e *Editor, _ []string) error { return e.editorDescribeKey() }},
+		{"command-line", "Run the command by name.", "", func(e *Editor, _ []string) error { return e.editorCommandLine() }},
+		{"edit", "Open the file instead of buffer.", "<file>", (*Editor).editorEdit},
+		{"set", "Set the options of config file.", "<option>=<value> ...", (*Editor).editorSet},
+	} {
+		commands[c.name] = c
+	}
+}
+
+// editorRunCommand runs the command with arguments. Keys are the keys or
+// the command line of command for messages.
+func (e *Editor) editorRunCommand(cmd command, keys string, args []string) error {
+	e.keys, e.command = keys, cmd.name
+	return cmd.run(e, args)
+}
+
+// editorQuit returns errQuit. Buffer with unsaved changes is quit after
+// quitTimes warnings.
+func (e *Editor) editorQuit() error {
+	if e.dirty && e.quitTimes > 0 && !e.options.Stdout {
+		e.editorSetStatusMessage("Warning!!! File has unsaved changes. Press %s %d more times to quit.", e.keys, e.quitTimes)
+		e.quitTimes--
+		return nil
+	}
+	e.editorRemoveSwap()
+	return errQuit
+}
+
+// editorPageMove moves cursor one screen up or down by direction dir
+// ARROW_UP or ARROW_DOWN.
+func (e *Editor) editorPageMove(dir int) {
+	if dir == ARROW_UP {
+		e.cursor.y = e.offset.row
+	} else {
+		e.cursor.y = e.offset.row + e.screen.rows - 1
+		if e.cursor.y > len(e.rows) {
+			e.cursor.y = len(e.rows)
+		}
+	}
+	for times := e.screen.rows; times > 0; times-- {
+		e.editorMoveCursor(dir)
+	}
+}
+
+// isWordChar returns true for bytes of words: letters, digits, underscore
+// and bytes of not ASCII characters.
+func isWordChar(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= 0x80
+}
+
+// editorMoveWord moves cursor to start of word before cursor or to start
+// of next word by direction dir ARROW_LEFT or ARROW_RIGHT. End of line is
+// a space between words.
+func (e *Editor) editorMoveWord(dir int) {
+	if dir == ARROW_LEFT {
+		for word := false; ; {
+			if e.cursor.y >= len(e.rows) || e.cursor.x == 0 {
+				if e.cursor.y == 0 || word {
+					return
+				}
+				e.editorMoveCursor(ARROW_LEFT)
+				continue
+			}
+			c := isWordChar(e.rows[e.cursor.y].chars[e.cursor.x-1])
+			if word && !c {
+				return
+			}
+			word = word || c
+			e.cursor.x--
+		}
+	}
+	for space := false; e.cursor.y < len(e.rows); {
+		row := &e.rows[e.cursor.y]
+		if e.cursor.x >= row.size {
+			if e.cursor.y == len(e.rows)-1 {
+				return
+			}
+			e.editorMoveCursor(ARROW_RIGHT)
+			space = true
+			continue
+		}
+		c := isWordChar(row.chars[e.cursor.x])
+		if space && c {
+			return
+		}
+		space = space || !c
+		e.cursor.x++
+	}
+}
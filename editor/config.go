@@ -0,0 +1,307 @@
+package editor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// config
+//
+// Config file "pe/config" of user config directory sets the settings of
+// editor. Project config files ".peconfig" in directory of edited file and
+// in its parents override it and EditorConfig files, the nearest file is
+// the last one. Options before the first section are global, options of
+// section "[filetype <type> ...]" are only for files of these types:
+//
+//	# comment
+//	tabstop = 4
+//	quit-times = 3
+//	message-timeout = 5s
+//	theme = dark
+//	color.match = fg=red bold
+//	key Ctrl-X Ctrl-S = save
+//
+//	[filetype go c]
+//	tabstop = 8
+//
+// Type of file is its extension without dot, for example "go", or the name
+// of file without extension, for example "Makefile". Color options
+// "color.<group>" set the style of highlight group over the theme, style
+// is the same as in theme file. Options "key <keys>" bind keys to commands,
+// see keymap.
+
+// KILO_MESSAGE_TIMEOUT is time of showing of status message.
+const KILO_MESSAGE_TIMEOUT = 5 * time.Second
+
+const (
+	CONFIG_FILE         = "config"    // in directory "pe" of user config directory
+	PROJECT_CONFIG_FILE = ".peconfig" // in directory of file or its parents
+)
+
+// settings are the settings of editor, that are set by config files.
+type settings struct {
+	tabStop        int
+	quitTimes      int
+	messageTimeout time.Duration
+	theme          string
+	colors         map[int]style // styles of highlight groups over theme
+	bindings       []binding     // bindings of keys over default keymap
+
+	// settings of EditorConfig
+	expandTab         bool   // Tab key inserts spaces
+	indentSize        int    // columns of indent, tab stop if zero
+	lineEnding        string // line ending of saved file
+	charset           string // encoding of saved file, if not empty
+	trimTrailingSpace bool   // trim spaces at end of lines on save
+	finalNewline      bool   // last line of saved file has line ending
+}
+
+// defaultSettings returns the settings without config files.
+func defaultSettings() settings {
+	return settings{
+		tabStop:        KILO_TAB_STOP,
+		quitTimes:      KILO_QUIT_TIMES,
+		messageTimeout: KILO_MESSAGE_TIMEOUT,
+		lineEnding:     "\n",
+		finalNewline:   true,
+	}
+}
+
+// configOption is the line "name = value" of config file.
+type configOption struct {
+	pos   string // file and line for errors
+	name  string
+	value string
+}
+
+// configFile is the parsed config file.
+type configFile struct {
+	global    []configOption
+	filetypes map[string][]configOption
+}
+
+// parseConfig parses the config file and validates its options.
+func parseConfig(r io.Reader, name string) (*configFile, error) {
+	c := &configFile{filetypes: map[string][]configOption{}}
+	section := []string{""} // types of current section, "" is global
+	check := defaultSettings()
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		pos := fmt.Sprintf("%s:%d", name, line)
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("%s: section is not closed by \"]\"", pos)
+			}
+			fields := strings.Fields(text[1 : len(text)-1])
+			if len(fields) < 2 || fields[0] != "filetype" {
+				return nil, fmt.Errorf("%s: unknown section %q, expected \"[filetype <type> ...]\"", pos, text)
+			}
+			section = fields[1:]
+			continue
+		}
+		eq := strings.IndexByte(text, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("%s: expected \"<option> = <value>\"", pos)
+		}
+		o := configOption{
+			pos:   pos,
+			name:  strings.TrimSpace(text[:eq]),
+			value: strings.TrimSpace(text[eq+1:]),
+		}
+		if err := check.set(o); err != nil {
+			return nil, err
+		}
+		for _, ft := range section {
+			if ft == "" {
+				c.global = append(c.global, o)
+			} else {
+				c.filetypes[ft] = append(c.filetypes[ft], o)
+			}
+		}
+	}
+	return c, scanner.Err()
+}
+
+// set sets the option of config file.
+func (s *settings) set(o configOption) (err error) {
+	positive := func() (int, error) {
+		v, err := strconv.Atoi(o.value)
+		if err != nil || v < 1 {
+			return 0, fmt.Errorf("bad number %q, expected positive number", o.value)
+		}
+		return v, nil
+	}
+	switch {
+	case o.name == "tabstop":
+		s.tabStop, err = positive()
+	case o.name == "quit-times":
+		s.quitTimes, err = strconv.Atoi(o.value)
+		if err != nil || s.quitTimes < 0 {
+			err = fmt.Errorf("bad number %q, expected not negative number", o.value)
+		}
+	case o.name == "message-timeout":
+		s.messageTimeout, err = time.ParseDuration(o.value)
+		if err != nil || s.messageTimeout <= 0 {
+			err = fmt.Errorf("bad duration %q, expected positive duration like \"5s\"", o.value)
+		}
+	case o.name == "theme":
+		if _, err = loadTheme(o.value); err == nil {
+			s.theme = o.value
+		}
+	case strings.HasPrefix(o.name, "color."):
+		group, ok := highlightGroup(strings.TrimPrefix(o.name, "color."))
+		if !ok {
+			return fmt.Errorf("%s: unknown highlight group %q", o.pos, strings.TrimPrefix(o.name, "color."))
+		}
+		var st style
+		if st, err = parseStyle(strings.Fields(o.value)); err == nil {
+			// settings are copied, map is not changed
+			colors := map[int]style{group: st}
+			for g, st := range s.colors {
+				if g != group {
+					colors[g] = st
+				}
+			}
+			s.colors = colors
+		}
+	case strings.HasPrefix(o.name, "key "):
+		var keys string
+		if keys, err = parseChord(strings.TrimPrefix(o.name, "key ")); err != nil {
+			break
+		}
+		if _, ok := commands[o.value]; !ok {
+			err = fmt.Errorf("unknown command %q", o.value)
+			break
+		}
+		s.bindings = append(s.bindings, binding{keys: keys, command: o.value})
+	default:
+		return fmt.Errorf("%s: unknown option %q", o.pos, o.name)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %s: %v", o.pos, o.name, err)
+	}
+	return nil
+}
+
+// apply sets global options of config file and options of filetype.
+func (c *configFile) apply(s *settings, filetype string) error {
+	options := c.global
+	if filetype != "" {
+		options = append(options[:len(options):len(options)], c.filetypes[filetype]...)
+	}
+	for _, o := range options {
+		if err := s.set(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadConfig reads the config file. Not existing file is nil config.
+func loadConfig(path string) (*configFile, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseConfig(f, path)
+}
+
+// userConfig reads the config file of user config directory.
+func userConfig() (*configFile, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, nil
+	}
+	return loadConfig(filepath.Join(dir, "pe", CONFIG_FILE))
+}
+
+// projectConfigs reads the project config files of directory of filename
+// and of its parents. The nearest file is the last one.
+func projectConfigs(filename string) (configs []*configFile, err error) {
+	dir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	for {
+		c, err := loadConfig(filepath.Join(dir, PROJECT_CONFIG_FILE))
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			configs = append([]*configFile{c}, configs...)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return configs, nil
+		}
+		dir = parent
+	}
+}
+
+// fileType returns the type of file for sections of config file.
+func fileType(filename string) string {
+	base := filepath.Base(filename)
+	if ext := filepath.Ext(base); ext != "" && ext != base {
+		return ext[1:]
+	}
+	return base
+}
+
+// editorLoadConfig sets the settings of user config, of EditorConfig and
+// of project config files for the file.
+func (e *Editor) editorLoadConfig(filename string) error {
+	s := defaultSettings()
+	ft := ""
+	if filename != "" {
+		ft = fileType(filename)
+	}
+	if e.config != nil {
+		if err := e.config.apply(&s, ft); err != nil {
+			return err
+		}
+	}
+	if e.options.Config && filename != "" {
+		props, err := editorConfigProperties(filename)
+		if err != nil {
+			return err
+		}
+		s.setEditorConfig(props)
+		project, err := projectConfigs(filename)
+		if err != nil {
+			return err
+		}
+		for _, c := range project {
+			if err := c.apply(&s, ft); err != nil {
+				return err
+			}
+		}
+	}
+	e.editorApplySettings(s)
+	return nil
+}
+
+// editorApplySettings sets the settings of buffer. Rows are rendered again
+// with the new tab stop.
+func (e *Editor) editorApplySettings(s settings) {
+	e.settings = s
+	e.quitTimes = s.quitTimes
+	e.keymap = newKeymap(append(defaultBindings[:len(defaultBindings):len(defaultBindings)], s.bindings...))
+	e.chord = nil
+	for i := range e.rows {
+		e.editorUpdateRow(&e.rows[i])
+	}
+}
@@ -0,0 +1,129 @@
+package editor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfigErrors(t *testing.T) {
+	tcs := []struct {
+		src, err string
+	}{
+		{"tabstop = 8\n\ntabstop 8", "x:3: expected \"<option> = <value>\""},
+		{"# comment\ntabstop = 0", "x:2: tabstop: bad number \"0\", expected positive number"},
+		{"quit-times = -1", "x:1: quit-times: bad number \"-1\", expected not negative number"},
+		{"message-timeout = 5", "x:1: message-timeout: bad duration \"5\", expected positive duration like \"5s\""},
+		{"theme = not-exist", "x:1: theme: unknown theme \"not-exist\""},
+		{"color.foo = bold", "x:1: unknown highlight group \"foo\""},
+		{"color.match = blink", "x:1: color.match: unknown attribute \"blink\""},
+		{"[filetype go]\ntabwidth = 8", "x:2: unknown option \"tabwidth\""},
+		{"[filetype]", "x:1: unknown section \"[filetype]\", expected \"[filetype <type> ...]\""},
+		{"[filetype go", "x:1: section is not closed by \"]\""},
+	}
+	for _, tc := range tcs {
+		_, err := parseConfig(strings.NewReader(tc.src), "x")
+		if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+			t.Errorf("%q: got error %v, expected %q", tc.src, err, tc.err)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+
+	write := func(name, content string) {
+		t.Helper()
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("config/pe/config", "quit-times = 1\nmessage-timeout = 2s\n[filetype go c]\ntabstop = 8\ncolor.match = fg=red\n")
+	write("project/"+PROJECT_CONFIG_FILE, "quit-times = 2\n[filetype go]\ntabstop = 2\n")
+	write("project/sub/"+PROJECT_CONFIG_FILE, "[filetype Makefile]\ntabstop = 3\n")
+	write("project/sub/main.go", "\tx\n")
+	write("project/sub/main.c", "\tx\n")
+	write("project/sub/Makefile", "\tx\n")
+
+	e, err := New(Options{Config: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.settings.quitTimes != 1 || e.settings.messageTimeout != 2*time.Second || e.settings.tabStop != KILO_TAB_STOP {
+		t.Fatalf("unexpected global settings: %+v", e.settings)
+	}
+
+	tcs := []struct {
+		file      string
+		tabStop   int
+		quitTimes int
+		colors    int
+	}{
+		{"main.go", 2, 2, 1},
+		{"main.c", 8, 2, 1},
+		{"Makefile", 3, 2, 0},
+	}
+	for _, tc := range tcs {
+		if err := e.Open(filepath.Join(dir, "project", "sub", tc.file)); err != nil {
+			t.Fatal(err)
+		}
+		s := e.settings
+		if s.tabStop != tc.tabStop || s.quitTimes != tc.quitTimes || len(s.colors) != tc.colors {
+			t.Errorf("%s: unexpected settings %+v", tc.file, s)
+		}
+		if e.rows[0].rsize != tc.tabStop+1 {
+			t.Errorf("%s: tab is rendered as %d spaces", tc.file, e.rows[0].rsize-1)
+		}
+	}
+
+	// config is not read without option
+	if e, err = New(Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Open(filepath.Join(dir, "project", "sub", "main.go")); err != nil {
+		t.Fatal(err)
+	}
+	if e.settings.tabStop != KILO_TAB_STOP || e.settings.quitTimes != KILO_QUIT_TIMES {
+		t.Errorf("unexpected settings without config: %+v", e.settings)
+	}
+
+	// errors of project config are errors of open
+	write("project/"+PROJECT_CONFIG_FILE, "tabstop = x\n")
+	e, _ = New(Options{Config: true})
+	err = e.Open(filepath.Join(dir, "project", "sub", "main.go"))
+	if expect := PROJECT_CONFIG_FILE + ":1: tabstop: bad number"; err == nil || !strings.Contains(err.Error(), expect) {
+		t.Errorf("got error %v, expected %q", err, expect)
+	}
+}
+
+func TestConfigColors(t *testing.T) {
+	c, err := parseConfig(strings.NewReader("theme = dark\ncolor.status = fg=red bold\n"), "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newTestEditor(t)
+	e.config = c
+	if err := e.editorLoadConfig(""); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COLORTERM", "")
+	if err := e.editorUpdateTheme(); err != nil {
+		t.Fatal(err)
+	}
+	// xterm has 8 colors, match is of theme "dark"
+	if e.highlight[HL_STATUS] != "\x1b[0;1;31m" || e.highlight[HL_MATCH] != "\x1b[0;1;34m" {
+		t.Errorf("unexpected highlight: %q", e.highlight)
+	}
+}
@@ -0,0 +1,1260 @@
+package editor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Terminal is the source of keys and window size of editor. Editor output
+// is written to the writer of Editor.Run.
+type Terminal interface {
+	ReadKey() (Key, error)
+	WindowSize() (rows, cols int, err error)
+	EnableMouse(enable bool) error
+	Suspend() error
+}
+
+// Console is the terminal of tty.
+type Console struct {
+	in      *os.File
+	out     *os.File
+	ti      *terminfo
+	input   inputBuffer
+	disable func() error // restore terminal mode, if raw mode is enabled
+	resized int32        // window size is changed, atomic
+	wait    sync.Locker  // unlocked while waiting for input, if not nil
+	idle    func()       // called on each poll without input, if not nil
+}
+
+func newConsole(in, out *os.File, ti *terminfo, escapeTimeout time.Duration) *Console {
+	c := &Console{in: in, out: out, ti: ti}
+	c.input.ti = ti
+	c.input.timeout = escapeTimeout
+	return c
+}
+
+// errResize is returned by read of terminal input after change of window
+// size.
+var errResize = errors.New("window size is changed")
+
+// KILO_RESIZE_POLL is interval of check of window size change during
+// waiting for input
+const KILO_RESIZE_POLL = 100 * time.Millisecond
+
+// start switches the terminal into raw mode, enters alternate screen and
+// keypad transmit mode, enables bracketed paste, xterm modifyOtherKeys and
+// kitty keyboard protocol for keys with modifiers.
+func (c *Console) start() error {
+	if c.disable != nil {
+		return nil
+	}
+	disable, err := enableRawMode(c.in.Fd())
+	if err != nil {
+		return err
+	}
+	c.disable = disable
+	_, err = io.WriteString(c.out, c.ti.str("smcup")+c.ti.str("smkx")+"\x1b[?2004h\x1b[>4;1m\x1b[>1u")
+	return err
+}
+
+// stop restores the terminal state before start.
+func (c *Console) stop() error {
+	if c.disable == nil {
+		return nil
+	}
+	// disable mouse, bracketed paste and key protocols, leave alternate
+	// screen
+	io.WriteString(c.out, "\x1b[<u\x1b[>4m\x1b[?1000l\x1b[?1002l\x1b[?1006l\x1b[?2004l"+c.ti.str("rmkx")+c.ti.str("rmcup"))
+	err := c.disable()
+	c.disable = nil
+	return err
+}
+
+// Suspend stops the process group by SIGTSTP in the terminal mode before
+// start and returns after SIGCONT in the raw mode.
+func (c *Console) Suspend() error {
+	if err := c.stop(); err != nil {
+		return err
+	}
+	if err := syscall.Kill(0, syscall.SIGTSTP); err != nil {
+		return err
+	}
+	return c.start()
+}
+
+func (c *Console) WindowSize() (rows, cols int, err error) {
+	w := struct {
+		Row, Col       uint16
+		Xpixel, Ypixel uint16
+	}{}
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL,
+		c.in.Fd(),
+		syscall.TIOCGWINSZ,
+		uintptr(unsafe.Pointer(&w)),
+	); e != 0 { // type syscall.Errno
+		// ioctl() isn’t guaranteed to be able to request the window size on all systems.
+		// The strategy is to position the cursor at the bottom-right of the
+		// screen, then use escape sequences that let us query the position
+		// of the cursor. That tells us how many rows and columns there must be
+		// on the screen.
+		io.WriteString(c.out, c.ti.str("cuf", 999)+c.ti.str("cud", 999)+c.ti.str("u7"))
+		var buffer [1]byte
+		var buf []byte
+		for cc, _ := c.in.Read(buffer[:]); cc == 1; cc, _ = c.in.Read(buffer[:]) {
+			if buffer[0] == 'R' {
+				break
+			}
+			buf = append(buf, buffer[0])
+		}
+		if string(buf[0:2]) != "\x1b[" {
+			return 0, 0, fmt.Errorf("Failed to read rows;cols from tty\n")
+		}
+		n, e := fmt.Sscanf(string(buf[2:]), "%d;%d", &rows, &cols)
+		if e != nil {
+			return 0, 0, fmt.Errorf("getCursorPosition: fmt.Sscanf() failed: %s\n", e)
+		}
+		if n != 2 {
+			return 0, 0, fmt.Errorf("getCursorPosition: got %d items, wanted 2\n", n)
+		}
+		return
+	}
+	return int(w.Row), int(w.Col), nil
+}
+
+func (c *Console) ReadKey() (Key, error) {
+	if c.input.read == nil {
+		c.input.read = func(timeout time.Duration) ([]byte, error) {
+			if timeout >= 0 {
+				return readTimeout(c.in.Fd(), timeout)
+			}
+			for {
+				if atomic.SwapInt32(&c.resized, 0) != 0 {
+					return nil, errResize
+				}
+				if c.wait != nil {
+					c.wait.Unlock()
+				}
+				b, err := readTimeout(c.in.Fd(), KILO_RESIZE_POLL)
+				if c.wait != nil {
+					c.wait.Lock()
+				}
+				if err != nil || len(b) > 0 {
+					return b, err
+				}
+				if c.idle != nil {
+					c.idle()
+				}
+			}
+		}
+	}
+	k, err := c.input.readKey()
+	if err == errResize {
+		return Key{Code: RESIZE_KEY}, nil
+	}
+	return k, err
+}
+
+// defines
+
+const KILO_TAB_STOP = 4
+const KILO_QUIT_TIMES = 3
+const (
+	BACKSPACE  = 127
+	ARROW_LEFT = 1000 + iota
+	ARROW_RIGHT
+	ARROW_UP
+	ARROW_DOWN
+	DEL_KEY
+	HOME_KEY
+	END_KEY
+	PAGE_UP
+	PAGE_DOWN
+)
+
+// highlight groups
+const (
+	HL_NORMAL = iota
+	HL_MATCH
+	HL_SELECTION
+	HL_STATUS  // status bar
+	HL_MESSAGE // message bar
+	HL_CONTROL // control characters
+	HL_NONTEXT // lines after end of buffer
+	HL_COUNT
+)
+
+// data
+
+type erow struct {
+	size   int
+	rsize  int
+	chars  []byte
+	render []byte
+	hl     []byte
+}
+
+// Options are settings of editor.
+type Options struct {
+	Stdout bool // write buffer to stdout on quit
+	Swap   bool // write swap file for crash recovery
+	Backup struct {
+		Enable bool   // keep previous version of file on save
+		Dir    string // directory for numbered backups
+		Keep   int    // amount of numbered backups
+	}
+	Encoding string // legacy encoding for not UTF-8 files
+
+	EscapeTimeout time.Duration // waiting of escape sequence rest
+	Mouse         bool          // enable mouse reporting
+	Theme         string        // name of color theme
+	Replay        *KeyScript    // keys played back before keys of terminal
+	Record        string        // path of key script for recording of keys
+	Config        bool          // read user config and project config files
+}
+
+// Editor is the editor of one buffer. Keys are read from its terminal and
+// the screen is written to its output.
+type Editor struct {
+	options   Options
+	term      Terminal
+	out       io.Writer
+	ti        *terminfo        // capabilities of terminal
+	highlight [HL_COUNT]string // SGR sequences of theme
+	drawn     screenState      // last frame on the terminal
+	now       func() time.Time // clock of status messages and swap
+	quitTimes int              // amount of Ctrl-Q for quit with changes
+	config    *configFile      // user config file
+	settings  settings         // settings of config files for the file
+	keymap    *keymap          // bindings of keys to commands
+	chord     []Key            // pending keys of chord
+	keys      string           // keys of last command
+	command   string           // name of last command
+	history   []string         // lines of command line
+
+	cursor    struct{ x, y int }
+	rx        int
+	offset    struct{ row, col int }
+	screen    struct{ rows, cols int }
+	rows      []erow
+	dirty     bool
+	filename  string
+	backup    string // file with "file~" backup of session
+	encoding  string // encoding of file
+	raw       []byte // raw content of binary file or of hex view
+	edited    bool   // rows are changed after raw
+	hex       hexView
+	mouse     bool // mouse reporting is enabled
+	selection struct {
+		active bool
+		anchor struct{ x, y int } // start of selection
+	}
+	status struct {
+		msg      string
+		msg_time time.Time
+	}
+	swap struct {
+		path    string    // path of written swap file
+		changes int       // amount of keypresses after last swap write
+		last    time.Time // time of last swap write
+	}
+}
+
+// New returns the editor with empty buffer.
+func New(options Options) (*Editor, error) {
+	if options.Encoding == "" {
+		options.Encoding = DEFAULT_LEGACY_ENCODING
+	}
+	if enc, ok := encodings[options.Encoding]; !ok || !enc.legacy {
+		return nil, fmt.Errorf("Unknown legacy encoding %q. Supported: %s", options.Encoding,
+			strings.Join(LegacyEncodingNames(), ", "))
+	}
+	if options.Theme != "" {
+		if _, err := loadTheme(options.Theme); err != nil {
+			return nil, err
+		}
+	}
+	e := &Editor{
+		options:   options,
+		out:       ioutil.Discard,
+		ti:        xterm,
+		highlight: defaultHighlight,
+		now:       time.Now,
+		encoding:  DEFAULT_ENCODING,
+	}
+	if options.Config {
+		var err error
+		if e.config, err = userConfig(); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.editorLoadConfig(""); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// filetypes
+
+// terminal
+
+func TcSetAttr(fd uintptr, termios *syscall.Termios) error {
+	// TCSETS+1 == TCSETSW, because TCSAFLUSH doesn't exist
+	if _, _, err := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		fd,
+		uintptr(syscall.TCSETS+1), uintptr(unsafe.Pointer(termios))); err != 0 {
+
+		return fmt.Errorf("TcSetAttr: %v", err)
+	}
+	return nil
+}
+
+// IsTerminal return true if fd is a terminal
+func IsTerminal(fd uintptr) bool {
+	var termios syscall.Termios
+	_, _, err := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		fd,
+		syscall.TCGETS,
+		uintptr(unsafe.Pointer(&termios)))
+	return err == 0
+}
+
+func TcGetAttr(fd uintptr) (*syscall.Termios, error) {
+	var termios = &syscall.Termios{}
+	if _, _, err := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		fd,
+		syscall.TCGETS,
+		uintptr(unsafe.Pointer(termios))); err != 0 {
+
+		return nil, fmt.Errorf("Problem getting terminal attributes: %s", err)
+	}
+	return termios, nil
+}
+
+// row operations
+//
+// Cursor x is the byte index in chars of row and always is at start of
+// UTF-8 character. Render column rx counts characters: tab is expanded up
+// to the next tab stop, other character is one column. Byte of invalid
+// UTF-8 sequence is one character.
+
+// runeStart returns the byte index of start of character, that contains
+// byte x of chars.
+func runeStart(chars []byte, x int) int {
+	j := 0
+	for j < x {
+		_, size := utf8.DecodeRune(chars[j:])
+		if j+size > x {
+			break
+		}
+		j += size
+	}
+	return j
+}
+
+func (e *Editor) editorRowCxToRx(row *erow, cx int) int {
+	rx := 0
+	for j := 0; j < row.size && j < cx; {
+		_, size := utf8.DecodeRune(row.chars[j:])
+		if row.chars[j] == '\t' {
+			rx += ((e.settings.tabStop - 1) - (rx % e.settings.tabStop))
+		}
+		rx++
+		j += size
+	}
+	return rx
+}
+
+func (e *Editor) editorRowRxToCx(row *erow, rx int) int {
+	curRx := 0
+	var cx int
+	for cx = 0; cx < row.size; {
+		_, size := utf8.DecodeRune(row.chars[cx:])
+		if row.chars[cx] == '\t' {
+			curRx += (e.settings.tabStop - 1) - (curRx % e.settings.tabStop)
+		}
+		curRx++
+		if curRx > rx {
+			break
+		}
+		cx += size
+	}
+	return cx
+}
+
+// editorUpdateRow updates render of row: chars with expanded tabs. Size
+// of render rsize is in columns.
+func (e *Editor) editorUpdateRow(row *erow) {
+	row.render = make([]byte, 0, row.size)
+	idx := 0
+	for j := 0; j < row.size; {
+		_, size := utf8.DecodeRune(row.chars[j:])
+		if row.chars[j] == '\t' {
+			row.render = append(row.render, ' ')
+			idx++
+			for (idx % e.settings.tabStop) != 0 {
+				row.render = append(row.render, ' ')
+				idx++
+			}
+		} else {
+			row.render = append(row.render, row.chars[j:j+size]...)
+			idx++
+		}
+		j += size
+	}
+	row.rsize = idx
+	row.hl = make([]byte, row.rsize)
+}
+
+// editorInsertRow inserts the row with copy of s. Rows never share the
+// arrays: append to one row must not overwrite the other.
+func (e *Editor) editorInsertRow(at int, s []byte) {
+	if at < 0 || at > len(e.rows) {
+		return
+	}
+	var r erow
+	r.chars = append([]byte{}, s...)
+	r.size = len(s)
+
+	if at == 0 {
+		t := make([]erow, 1)
+		t[0] = r
+		e.rows = append(t, e.rows...)
+	} else if at == len(e.rows) {
+		e.rows = append(e.rows, r)
+	} else {
+		t := make([]erow, 1)
+		t[0] = r
+		e.rows = append(e.rows[:at], append(t, e.rows[at:]...)...)
+	}
+
+	e.editorUpdateRow(&e.rows[at])
+	e.dirty, e.edited = true, true
+}
+
+func (e *Editor) editorDelRow(at int) {
+	if at < 0 || at >= len(e.rows) {
+		return
+	}
+	e.rows = append(e.rows[:at], e.rows[at+1:]...)
+	e.dirty, e.edited = true, true
+}
+
+func (e *Editor) editorRowInsertChar(row *erow, at int, c byte) {
+	if at < 0 || at > row.size {
+		row.chars = append(row.chars, c)
+	} else if at == 0 {
+		t := make([]byte, row.size+1)
+		t[0] = c
+		copy(t[1:], row.chars)
+		row.chars = t
+	} else {
+		row.chars = append(
+			row.chars[:at],
+			append(append(make([]byte, 0), c), row.chars[at:]...)...,
+		)
+	}
+	row.size = len(row.chars)
+	e.editorUpdateRow(row)
+	e.dirty, e.edited = true, true
+}
+
+func (e *Editor) editorRowAppendString(row *erow, s []byte) {
+	row.chars = append(row.chars, s...)
+	row.size = len(row.chars)
+	e.editorUpdateRow(row)
+	e.dirty, e.edited = true, true
+}
+
+// editorRowDelChar deletes the character, that starts at byte at.
+func (e *Editor) editorRowDelChar(row *erow, at int) {
+	if at < 0 || at >= row.size {
+		return
+	}
+	_, size := utf8.DecodeRune(row.chars[at:])
+	row.chars = append(row.chars[:at], row.chars[at+size:]...)
+	row.size = len(row.chars)
+	e.dirty, e.edited = true, true
+	e.editorUpdateRow(row)
+}
+
+// editor operations
+
+// InsertChar inserts character c at cursor.
+func (e *Editor) InsertChar(c byte) {
+	if e.cursor.y == len(e.rows) {
+		var emptyRow []byte
+		e.editorInsertRow(len(e.rows), emptyRow)
+	}
+	e.editorRowInsertChar(&e.rows[e.cursor.y], e.cursor.x, c)
+	e.cursor.x++
+}
+
+// editorInsertTab inserts tab or, if indent style is space, spaces up to
+// the next indent column.
+func (e *Editor) editorInsertTab() {
+	if !e.settings.expandTab {
+		e.InsertChar('\t')
+		return
+	}
+	size := e.settings.indentSize
+	if size == 0 {
+		size = e.settings.tabStop
+	}
+	rx := 0
+	if e.cursor.y < len(e.rows) {
+		rx = e.editorRowCxToRx(&e.rows[e.cursor.y], e.cursor.x)
+	}
+	for n := size - rx%size; n > 0; n-- {
+		e.InsertChar(' ')
+	}
+}
+
+// InsertNewLine splits the row at cursor.
+func (e *Editor) InsertNewLine() {
+	if e.cursor.x == 0 {
+		e.editorInsertRow(e.cursor.y, make([]byte, 0))
+	} else {
+		e.editorInsertRow(e.cursor.y+1, e.rows[e.cursor.y].chars[e.cursor.x:])
+		e.rows[e.cursor.y].chars = e.rows[e.cursor.y].chars[:e.cursor.x]
+		e.rows[e.cursor.y].size = len(e.rows[e.cursor.y].chars)
+		e.editorUpdateRow(&e.rows[e.cursor.y])
+	}
+	e.cursor.y++
+	e.cursor.x = 0
+}
+
+// InsertText inserts the text at the cursor as one operation.
+func (e *Editor) InsertText(text []byte) {
+	text = bytes.Replace(text, []byte("\r\n"), []byte("\n"), -1)
+	text = bytes.Replace(text, []byte("\r"), []byte("\n"), -1)
+	if len(text) == 0 {
+		return
+	}
+	if e.cursor.y == len(e.rows) {
+		e.editorInsertRow(len(e.rows), nil)
+	}
+	lines := bytes.Split(text, []byte("\n"))
+	row := &e.rows[e.cursor.y]
+	after := append([]byte{}, row.chars[e.cursor.x:]...)
+	row.chars = append(row.chars[:e.cursor.x], lines[0]...)
+	for i, line := range lines[1:] {
+		e.editorInsertRow(e.cursor.y+1+i, line)
+	}
+	e.cursor.y += len(lines) - 1
+	row = &e.rows[e.cursor.y]
+	e.cursor.x = len(row.chars)
+	row.chars = append(row.chars, after...)
+	for y := e.cursor.y - len(lines) + 1; y <= e.cursor.y; y++ {
+		e.rows[y].size = len(e.rows[y].chars)
+		e.editorUpdateRow(&e.rows[y])
+	}
+	e.dirty, e.edited = true, true
+}
+
+// DelChar deletes character before cursor.
+func (e *Editor) DelChar() {
+	if e.cursor.y == len(e.rows) {
+		return
+	}
+	if e.cursor.x == 0 && e.cursor.y == 0 {
+		return
+	}
+	if e.cursor.x > 0 {
+		row := &e.rows[e.cursor.y]
+		e.cursor.x = runeStart(row.chars, e.cursor.x-1)
+		e.editorRowDelChar(row, e.cursor.x)
+	} else {
+		e.cursor.x = e.rows[e.cursor.y-1].size
+		e.editorRowAppendString(&e.rows[e.cursor.y-1], e.rows[e.cursor.y].chars)
+		e.editorDelRow(e.cursor.y)
+		e.cursor.y--
+	}
+}
+
+// file I/O
+
+func (e *Editor) editorRowsToString() (string, int) {
+	totlen := 0
+	buf := ""
+	for _, row := range e.rows {
+		totlen += row.size + 1
+		buf += string(row.chars) + "\n"
+	}
+	return buf, totlen
+}
+
+// editorRowsToFile returns the content of file with line ending and
+// whitespace of settings.
+func (e *Editor) editorRowsToFile() string {
+	var buf strings.Builder
+	for i, row := range e.rows {
+		chars := row.chars
+		if e.settings.trimTrailingSpace {
+			chars = bytes.TrimRight(chars, " \t")
+		}
+		buf.Write(chars)
+		if i < len(e.rows)-1 || e.settings.finalNewline {
+			buf.WriteString(e.settings.lineEnding)
+		}
+	}
+	return buf.String()
+}
+
+// Open replaces the buffer by the file. File name is kept for Save.
+func (e *Editor) Open(filename string) error {
+	e.filename = filename
+	if err := e.editorLoadConfig(filename); err != nil {
+		return err
+	}
+	fd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	return e.Load(fd)
+}
+
+// Load replaces the buffer by rows from r. Content is converted from
+// the charset of settings or from the detected encoding into UTF-8.
+func (e *Editor) Load(r io.Reader) error {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	e.rows = nil
+	e.cursor.x, e.cursor.y = 0, 0
+	e.offset.row, e.offset.col = 0, 0
+	e.selection.active = false
+	e.raw = nil
+	e.hex = hexView{}
+	var enc encoding
+	if e.settings.charset != "" {
+		enc = encodings[e.settings.charset]
+		content = bytes.TrimPrefix(content, enc.bom)
+	} else {
+		enc, content = detectEncoding(content, e.options.Encoding)
+	}
+	e.encoding = enc.name
+	utf16 := enc.name == "utf-16le" || enc.name == "utf-16be"
+	if utf16 && len(content)%2 != 0 || !utf16 && bytes.IndexByte(content, 0) >= 0 {
+		// keep binary content for saving byte-for-byte
+		e.raw = append(append([]byte{}, enc.bom...), content...)
+		if keys := e.keymap.keysOf("toggle-hex"); keys != "" {
+			e.editorSetStatusMessage("Binary file detected. Press %s for hex view.", keys)
+		} else {
+			e.editorSetStatusMessage("Binary file detected.")
+		}
+	}
+	e.editorSetContent(enc.decode(content))
+	e.dirty, e.edited = false, false
+	return nil
+}
+
+// editorSetContent appends rows of UTF-8 content to the buffer. Lines
+// are split by carriage returns too, if it is line ending of settings.
+func (e *Editor) editorSetContent(content []byte) {
+	eol := byte('\n')
+	if e.settings.lineEnding == "\r" {
+		eol = '\r'
+	}
+	for len(content) > 0 {
+		var line []byte
+		if i := bytes.IndexByte(content, eol); i >= 0 {
+			line, content = content[:i], content[i+1:]
+		} else {
+			line, content = content, nil
+		}
+		// Trim trailing carriage returns
+		line = bytes.TrimRight(line, "\r")
+		e.editorInsertRow(len(e.rows), line)
+	}
+}
+
+// editorSaveEncoding asks encoding and saves the buffer in it.
+func (e *Editor) editorSaveEncoding() error {
+	name, err := e.editorPrompt("Save with encoding: %s (ESC to cancel)", nil)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		e.editorSetStatusMessage("Save aborted")
+		return nil
+	}
+	if _, ok := encodings[name]; !ok {
+		e.editorSetStatusMessage("Unknown encoding %q. Supported: %s", name,
+			strings.Join(EncodingNames(), ", "))
+		return nil
+	}
+	e.encoding = name
+	e.dirty, e.edited = true, true
+	return e.Save()
+}
+
+// Save writes the buffer to file. Errors are shown in status message.
+func (e *Editor) Save() (err error) {
+	if e.filename == "" {
+		e.filename, err = e.editorPrompt("Save as: %q", nil)
+		if err != nil {
+			return fmt.Errorf("Cannot save : %v", err)
+		}
+		if e.filename == "" {
+			e.editorSetStatusMessage("Save aborted")
+			return
+		}
+	}
+	buf, serr := e.Content()
+	if serr != nil {
+		e.editorSetStatusMessage("Can't save! %s", serr)
+		return
+	}
+	len := len(buf)
+	if serr := e.editorBackup(e.filename); serr != nil {
+		e.editorSetStatusMessage("Can't save! backup error %s", serr)
+		return
+	}
+	fp, serr := os.OpenFile(e.filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if serr != nil {
+		e.editorSetStatusMessage("Can't save! file open error %s", serr)
+		return
+	}
+	defer fp.Close()
+	n, err := fp.Write(buf)
+	if err == nil {
+		if n == len {
+			e.dirty = false
+			if e.raw != nil || e.hex.enable {
+				e.raw, e.edited = buf, false
+			}
+			e.editorRemoveSwap()
+			e.editorSetStatusMessage("%d bytes written to disk", len)
+		} else {
+			e.editorSetStatusMessage("wanted to write %d bytes to file, wrote %d", len, n)
+		}
+		return
+	}
+	e.editorSetStatusMessage("Can't save! I/O error %s", err)
+	return nil
+}
+
+// input
+
+// editorPrompt reads the line in message bar. Callback is called with
+// zero key before the first key and after each key, it returns the new
+// line and the hint shown after the line.
+func (e *Editor) editorPrompt(prompt string, callback func(buf []byte, k Key) ([]byte, string)) (string, error) {
+	var buf []byte
+	var hint string
+	if callback != nil {
+		buf, hint = callback(buf, Key{})
+	}
+
+	for {
+		e.editorSetStatusMessage("%s%s", fmt.Sprintf(prompt, buf), hint)
+		if err := e.Refresh(); err != nil {
+			return "", err
+		}
+
+		k, err := e.term.ReadKey()
+		if err != nil {
+			return "", err
+		}
+		c := k.Code
+		switch c {
+		case DEL_KEY, ('h' & 0x1f), BACKSPACE:
+			if len(buf) > 0 {
+				buf = buf[:len(buf)-1]
+			}
+		case '\x1b':
+			e.editorSetStatusMessage("")
+			if callback != nil {
+				callback(buf, k)
+			}
+			return "", nil
+		case PASTE_KEY:
+			for _, c := range []byte(k.Text) {
+				if c == '\r' || c == '\n' {
+					break
+				}
+				if unicode.IsPrint(rune(c)) {
+					buf = append(buf, c)
+				}
+			}
+		case '\r':
+			if len(buf) != 0 {
+				e.editorSetStatusMessage("")
+				if callback != nil {
+					callback(buf, k)
+				}
+				return string(buf), nil
+			}
+		default:
+			if k.Mod&(MOD_ALT|MOD_CTRL|MOD_META) == 0 && c < 0x100 && unicode.IsPrint(rune(c)) {
+				buf = append(buf, byte(c))
+			}
+		}
+
+		if callback != nil {
+			buf, hint = callback(buf, k)
+		}
+	}
+}
+
+func (e *Editor) editorMoveCursor(key int) {
+	switch key {
+	case ARROW_LEFT:
+		if e.cursor.x != 0 {
+			e.cursor.x = runeStart(e.rows[e.cursor.y].chars, e.cursor.x-1)
+		} else if e.cursor.y > 0 {
+			e.cursor.y--
+			e.cursor.x = e.rows[e.cursor.y].size
+		}
+	case ARROW_RIGHT:
+		if e.cursor.y < len(e.rows) {
+			if row := &e.rows[e.cursor.y]; e.cursor.x < row.size {
+				_, size := utf8.DecodeRune(row.chars[e.cursor.x:])
+				e.cursor.x += size
+			} else if e.cursor.x == e.rows[e.cursor.y].size {
+				e.cursor.y++
+				e.cursor.x = 0
+			}
+		}
+	case ARROW_UP:
+		if e.cursor.y != 0 {
+			e.cursor.y--
+		}
+	case ARROW_DOWN:
+		if e.cursor.y < len(e.rows) {
+			e.cursor.y++
+		}
+	}
+
+	rowlen := 0
+	if e.cursor.y < len(e.rows) {
+		rowlen = e.rows[e.cursor.y].size
+	}
+	if e.cursor.x > rowlen {
+		e.cursor.x = rowlen
+	}
+	if e.cursor.y < len(e.rows) {
+		// not in the middle of character of other row
+		e.cursor.x = runeStart(e.rows[e.cursor.y].chars, e.cursor.x)
+	}
+}
+
+// ProcessKeypress reads the key from terminal and processes it.
+func (e *Editor) ProcessKeypress() (outOfProgram bool, err error) {
+	k, err := e.term.ReadKey()
+	if err != nil {
+		return false, err
+	}
+	c := k.Code
+	if c == RESIZE_KEY {
+		err = e.editorUpdateWindowSize()
+		e.editorInvalidateScreen()
+		return
+	}
+	if e.hex.enable && e.editorHexProcessKey(k) {
+		e.quitTimes = e.settings.quitTimes
+		return
+	}
+	if c != MOUSE_KEY {
+		e.selection.active = false
+	}
+	switch c {
+	case PASTE_KEY:
+		e.chord = nil
+		e.InsertText([]byte(k.Text))
+	case MOUSE_KEY:
+		e.chord = nil
+		e.editorMouse(k.Mouse)
+	default:
+		cmd, keys, ok := e.editorKeyCommand(k)
+		if !ok {
+			// wait for next key of chord
+			return
+		}
+		if e.hex.enable && !hexCommands[cmd.name] {
+			cmd = commands["none"]
+		}
+		if err = e.editorRunCommand(cmd, keys, nil); err == errQuit {
+			return true, nil
+		}
+		if e.command == "quit" {
+			// quit is confirmed by repeat
+			return
+		}
+	}
+	e.quitTimes = e.settings.quitTimes
+	return
+}
+
+// output
+
+func (e *Editor) editorScroll() {
+	e.rx = 0
+	if e.cursor.y < len(e.rows) {
+		e.rx = e.editorRowCxToRx(&(e.rows[e.cursor.y]), e.cursor.x)
+	}
+	if e.cursor.y < e.offset.row {
+		e.offset.row = e.cursor.y
+	}
+	if e.cursor.y >= e.offset.row+e.screen.rows {
+		e.offset.row = e.cursor.y - e.screen.rows + 1
+	}
+	if e.rx < e.offset.col {
+		e.offset.col = e.rx
+	}
+	if e.rx >= e.offset.col+e.screen.cols {
+		e.offset.col = e.rx - e.screen.cols + 1
+	}
+}
+
+// Refresh draws the changes of screen to output.
+func (e *Editor) Refresh() error {
+	ab := bytes.NewBuffer(nil)
+	var f screenFrame
+	f.cols = e.screen.cols
+	if e.hex.enable {
+		f.cursor.y, f.cursor.x = e.editorHexScroll()
+		e.editorDrawHex(ab)
+	} else {
+		e.editorScroll()
+		e.editorDrawRows(ab)
+		f.cursor.y, f.cursor.x = e.cursor.y-e.offset.row, e.rx-e.offset.col
+	}
+	e.editorDrawStatusBar(ab)
+	e.editorDrawMessageBar(ab)
+	f.lines = splitScreenLines(ab.Bytes())
+
+	ab.Reset()
+	e.render(f, ab)
+	if _, err := ab.WriteTo(e.out); err != nil {
+		return fmt.Errorf("Cannot refresh screen : %v", err)
+	}
+	return nil
+}
+
+func (e *Editor) editorDrawRows(ab *bytes.Buffer) {
+	for y := 0; y < e.screen.rows; y++ {
+		filerow := y + e.offset.row
+		if filerow >= len(e.rows) {
+			ab.WriteString(e.highlight[HL_NONTEXT])
+			ab.WriteString("~")
+			ab.WriteString("\x1b[m")
+		} else {
+			row := &e.rows[filerow]
+			if row.rsize > e.offset.col {
+				selStart, selEnd, selected := e.editorSelectionRx(filerow)
+				currentAttr := ""
+				render := row.render
+				for rx := 0; rx < row.rsize && rx < e.offset.col+e.screen.cols; rx++ {
+					r, size := utf8.DecodeRune(render)
+					ch := render[:size]
+					render = render[size:]
+					if rx < e.offset.col {
+						continue
+					}
+					h := row.hl[rx]
+					if selected && selStart <= rx && (rx < selEnd || selEnd < 0) {
+						h = HL_SELECTION
+					}
+					if r == utf8.RuneError && size == 1 || unicode.IsControl(r) {
+						// invalid UTF-8 byte or control character
+						h = HL_CONTROL
+						if r < 26 {
+							ch = []byte{'@'}
+						} else {
+							ch = []byte{'?'}
+						}
+					}
+					if attr := e.highlight[h]; attr != currentAttr {
+						ab.WriteString(attr)
+						currentAttr = attr
+					}
+					ab.Write(ch)
+				}
+				ab.WriteString("\x1b[m")
+			}
+		}
+		ab.WriteString("\x1b[K")
+		ab.WriteString("\r\n")
+	}
+}
+
+func (e *Editor) editorDrawStatusBar(ab *bytes.Buffer) {
+	ab.WriteString(e.highlight[HL_STATUS])
+	fname := filepath.Base(e.filename)
+	if e.filename == "" {
+		fname = "[No Name]"
+	}
+	modified := ""
+	if e.dirty {
+		modified = "(modified)"
+	}
+	status := fmt.Sprintf("%.20s - %d lines %s", fname, len(e.rows), modified)
+	ln := len(status)
+	if ln > e.screen.cols {
+		ln = e.screen.cols
+	}
+	filetype := "no ft"
+	// if e.syntax != nil {
+	// 	filetype = e.syntax.filetype
+	// }
+	rstatus := fmt.Sprintf("%s | %s | %d/%d", filetype, e.encoding, e.cursor.y+1, len(e.rows))
+	if e.hex.enable {
+		mode := "OVR"
+		if e.hex.insert {
+			mode = "INS"
+		}
+		rstatus = fmt.Sprintf("hex %s | 0x%08x/%d", mode, e.hex.cursor, len(e.hex.data))
+	}
+	rlen := len(rstatus)
+	ab.WriteString(status[:ln])
+	for ln < e.screen.cols {
+		if e.screen.cols-ln == rlen {
+			ab.WriteString(rstatus)
+			break
+		} else {
+			ab.WriteString(" ")
+			ln++
+		}
+	}
+	ab.WriteString("\x1b[m")
+	ab.WriteString("\r\n")
+}
+
+func (e *Editor) editorDrawMessageBar(ab *bytes.Buffer) {
+	ab.WriteString("\x1b[K")
+	msglen := len(e.status.msg)
+	if msglen > e.screen.cols {
+		msglen = e.screen.cols
+	}
+	if msglen > 0 && (e.now().Sub(e.status.msg_time) < e.settings.messageTimeout) {
+		ab.WriteString(e.highlight[HL_MESSAGE])
+		ab.WriteString(e.status.msg[:msglen])
+		ab.WriteString("\x1b[m")
+	}
+}
+
+func (e *Editor) editorSetStatusMessage(format string, a ...interface{}) {
+	e.status.msg = fmt.Sprintf(format, a...)
+	e.status.msg_time = e.now()
+}
+
+// init
+
+// RunTerminal runs the editor on the alternate screen of terminal in raw
+// mode. The original screen and terminal mode are restored on any exit:
+// return, error, panic and termination signal. Termination signal writes
+// the swap file of unsaved changes and exits the process.
+func (e *Editor) RunTerminal(in, out *os.File) (err error) {
+	ti := xterm
+	if t, err := loadTerminfo(os.Getenv("TERM")); err == nil {
+		ti = t
+	}
+	e.ti = ti
+	console := newConsole(in, out, ti, e.options.EscapeTimeout)
+	var term Terminal = console
+	if e.options.Replay != nil {
+		term = newReplay(e, console, e.options.Replay)
+	}
+	if err = console.start(); err != nil {
+		return err
+	}
+	var (
+		mu      sync.Mutex
+		rec     *recorder
+		recFile *os.File
+	)
+	restore := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if e := console.stop(); e != nil && err == nil {
+			err = e
+		}
+		if rec != nil {
+			// keys are recorded until quit or termination signal
+			e := rec.flush()
+			if e2 := recFile.Close(); e == nil {
+				e = e2
+			}
+			if e != nil && err == nil {
+				err = fmt.Errorf("cannot record keys: %v", e)
+			}
+			rec = nil
+		}
+	}
+	defer restore()
+
+	if e.options.Record != "" {
+		f, err := os.OpenFile(e.options.Record, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		r, err := newRecorder(term, f, e.now)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		mu.Lock()
+		rec, recFile = r, f
+		mu.Unlock()
+		term = r
+	}
+
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer signal.Stop(resize)
+	go func() {
+		for range resize {
+			atomic.StoreInt32(&console.resized, 1)
+		}
+	}()
+
+	// editor is changed only while busy is locked: by the main loop except
+	// of waiting for input or by the termination signal
+	var busy sync.Mutex
+	busy.Lock()
+	console.wait, console.idle = &busy, e.editorIdleSwap
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT)
+	defer signal.Stop(signals)
+	go func() {
+		if sig, ok := <-signals; ok {
+			// busy is not unlocked after the main loop, so the swap file
+			// removed on quit is not written again
+			busy.Lock()
+			e.editorSyncSwap()
+			restore()
+			fmt.Fprintf(os.Stderr, "pe: %v\n", sig)
+			os.Exit(1)
+		}
+	}()
+
+	return e.Run(term, out)
+}
+
+// enableRawMode switches terminal fd in raw mode and returns function for
+// restoring original mode.
+func enableRawMode(fd uintptr) (disable func() error, err error) {
+	origTermios, err := TcGetAttr(fd)
+	if err != nil {
+		return nil, err
+	}
+	var raw syscall.Termios
+	raw = *origTermios
+	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Cflag |= syscall.CS8
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	raw.Cc[syscall.VMIN+1] = 0
+	raw.Cc[syscall.VTIME+1] = 1
+	if e := TcSetAttr(fd, &raw); e != nil {
+		return nil, fmt.Errorf("Problem enabling raw mode: %s", e)
+	}
+	return func() error {
+		if e := TcSetAttr(fd, origTermios); e != nil {
+			return fmt.Errorf("Problem disabling raw mode: %s", e)
+		}
+		return nil
+	}, nil
+}
+
+func (e *Editor) initEditor() (err error) {
+	// Initialization a la C not necessary.
+	if err = e.editorUpdateWindowSize(); err != nil {
+		return err
+	}
+	if e.encoding == "" {
+		e.encoding = DEFAULT_ENCODING
+	}
+	return e.editorUpdateTheme()
+}
+
+// editorUpdateWindowSize queries size of terminal window.
+func (e *Editor) editorUpdateWindowSize() (err error) {
+	if e.screen.rows, e.screen.cols, err = e.term.WindowSize(); err != nil {
+		return fmt.Errorf("couldn't get screen size: %v", err)
+	}
+	e.screen.rows -= 2
+	return nil
+}
+
+// editorSuspend suspends the editor and restores its screen after resume.
+func (e *Editor) editorSuspend() error {
+	if err := e.term.Suspend(); err != nil {
+		return err
+	}
+	if e.mouse {
+		if err := e.term.EnableMouse(true); err != nil {
+			return err
+		}
+	}
+	if err := e.editorUpdateWindowSize(); err != nil {
+		return err
+	}
+	e.editorInvalidateScreen()
+	return nil
+}
+
+// Run runs the editor with keys of terminal t and writes the screen to out
+// until quit.
+func (e *Editor) Run(t Terminal, out io.Writer) error {
+	e.term, e.out = t, out
+	err := e.initEditor()
+	if err != nil {
+		return fmt.Errorf("Cannot initialize editor: %v", err)
+	}
+	if err := e.editorCheckSwap(); err != nil {
+		return err
+	}
+
+	if e.options.Mouse {
+		if err := e.term.EnableMouse(true); err != nil {
+			return err
+		}
+		e.mouse = true
+		defer func() {
+			e.term.EnableMouse(false)
+			e.mouse = false
+		}()
+	}
+
+	if e.status.msg == "" {
+		e.editorSetStatusMessage("%s", e.editorHelp())
+	}
+
+	for {
+		if err := e.Refresh(); err != nil {
+			return err
+		}
+		quit, err := e.ProcessKeypress()
+		if err != nil {
+			return err
+		}
+		if quit {
+			// if enable close key
+			break
+		}
+		e.editorUpdateSwap()
+	}
+	return nil
+}
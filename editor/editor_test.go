@@ -0,0 +1,611 @@
+package editor
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var update = flag.Bool("update", false, "update snapshots of screen in testdata")
+
+type Mock struct {
+	pos        int
+	line       []Key
+	mouse      bool
+	suspended  int
+	rows, cols int           // window size, 100x100 by default
+	onRead     func(pos int) // called before reading of key
+}
+
+func (m *Mock) ReadKey() (Key, error) {
+	defer func() {
+		m.pos++
+	}()
+	if m.onRead != nil {
+		m.onRead(m.pos)
+	}
+	return m.line[m.pos], nil
+}
+
+func (m *Mock) EnableMouse(enable bool) error {
+	m.mouse = enable
+	return nil
+}
+
+func (m *Mock) Suspend() error {
+	m.suspended++
+	return nil
+}
+
+func (m Mock) WindowSize() (rows, cols int, err error) {
+	if m.rows == 0 {
+		return 100, 100, nil
+	}
+	return m.rows, m.cols, nil
+}
+
+func TestEditor(t *testing.T) {
+	t.Parallel()
+	for prefix := 0; ; prefix++ {
+		keys, _ := filepath.Abs(fmt.Sprintf("./testdata/%d.keys", prefix))
+		text, _ := filepath.Abs(fmt.Sprintf("./testdata/%d.file", prefix))
+		screens, _ := filepath.Abs(fmt.Sprintf("./testdata/%d.screen", prefix))
+		input, _ := filepath.Abs(fmt.Sprintf("./testdata/%d.input", prefix))
+		if _, err := os.Stat(keys); os.IsNotExist(err) {
+			break
+		}
+		if _, err := os.Stat(text); os.IsNotExist(err) {
+			break
+		}
+		t.Run(strconv.Itoa(prefix), func(t *testing.T) {
+			t.Parallel()
+			// parse keys
+			f, err := os.Open(keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			script, err := ParseKeyScript(f)
+			f.Close()
+			if err != nil {
+				t.Fatalf("%s: %v", keys, err)
+			}
+			e := newTestEditor(t)
+			h := newHeadless(e, script)
+
+			// snapshots of screen
+			var sf screenFile
+			if content, err := ioutil.ReadFile(screens); err == nil {
+				if sf, err = parseScreenFile(string(content)); err != nil {
+					t.Fatalf("%s: %v", screens, err)
+				}
+			} else if !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			if r := script.size; r.rows == 0 {
+				// legacy keys are recorded in window 100x100
+				h.rows, h.cols = 100, 100
+				if sf.rows != 0 {
+					h.rows, h.cols = sf.rows, sf.cols
+				}
+			} else if sf.rows != 0 && (sf.rows != r.rows || sf.cols != r.cols) {
+				t.Fatalf("window size %dx%d of keys is not %dx%d of screen",
+					r.rows, r.cols, sf.rows, sf.cols)
+			}
+			v := newVT(h.rows, h.cols)
+
+			// keys are recorded for comparing with script
+			var recording bytes.Buffer
+			rec, err := newRecorder(h, &recording, h.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			snapshots := map[string]string{}
+			h.onRead = func(pos int) {
+				snapshots[fmt.Sprintf("key %d", pos)] = v.snapshot()
+				if r, ok := script.resizes[pos]; ok {
+					v.resize(r.rows, r.cols)
+				}
+			}
+
+			// create temp file with the same name in status bar
+			filename := filepath.Join(t.TempDir(), "file.txt")
+			content, err := ioutil.ReadFile(input)
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(filename, content, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Open(filename); err != nil {
+				t.Fatal(err)
+			}
+
+			// run editor with keys
+			if err := e.runScript(h, rec, v); err != nil {
+				t.Fatalf("%s: %v", keys, err)
+			}
+			snapshots["final"] = v.snapshot()
+			if len(v.unknown) > 0 {
+				t.Errorf("not supported output: %q", v.unknown)
+			}
+
+			// compare recorded keys
+			if err := rec.flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := ParseKeyScript(bytes.NewReader(recording.Bytes())); err != nil {
+				t.Errorf("recording: %v", err)
+			} else if !reflect.DeepEqual(got.keys, script.keys) ||
+				!reflect.DeepEqual(got.resizes, script.resizes) ||
+				!reflect.DeepEqual(got.waits, script.waits) {
+				t.Errorf("recording is not the same as script:\n%s", recording.String())
+			}
+
+			// compare screen
+			for _, step := range sf.steps {
+				got, ok := snapshots[step]
+				switch {
+				case !ok:
+					t.Errorf("%s: no screen", step)
+				case *update:
+					sf.snapshots[step] = got
+				case got != sf.snapshots[step]:
+					t.Errorf("%s:%s", step, ShowDiff(sf.snapshots[step], got))
+				}
+			}
+			if *update && len(sf.steps) > 0 {
+				if err := ioutil.WriteFile(screens, []byte(sf.String()), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// compare files content
+			{
+				b1, err := ioutil.ReadFile(text)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b2, err := ioutil.ReadFile(e.filename)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(b1, b2) {
+					t.Fatal(ShowDiff(string(b1), string(b2)))
+				}
+			}
+		})
+	}
+}
+
+// newTestEditor returns the editor with default options.
+func newTestEditor(t testing.TB) *Editor {
+	t.Helper()
+	e, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestInsertText(t *testing.T) {
+	t.Parallel()
+	e := newTestEditor(t)
+	e.editorInsertRow(0, []byte("first"))
+	e.editorInsertRow(1, []byte("second"))
+	e.cursor.y, e.cursor.x = 0, 2
+
+	e.InsertText([]byte("ONE\r\nTWO\rTHREE"))
+
+	expect := "fiONE\nTWO\nTHREErst\nsecond\n"
+	if str, _ := e.editorRowsToString(); str != expect {
+		t.Fatal(ShowDiff(expect, str))
+	}
+	if e.cursor.y != 2 || e.cursor.x != 5 {
+		t.Fatalf("unexpected cursor position: %d %d", e.cursor.y, e.cursor.x)
+	}
+}
+
+func TestEditLegacyText(t *testing.T) {
+	t.Parallel()
+	e, err := New(Options{Encoding: "windows-1251"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.screen.rows, e.screen.cols = 2, 20
+	// "Привет" and tab with control character
+	if err := e.Load(strings.NewReader("\xCF\xF0\xE8\xE2\xE5\xF2\n\t\x01\n")); err != nil {
+		t.Fatal(err)
+	}
+	var keys []Key
+	for _, name := range []string{"ArrowRight", "ArrowRight", "ArrowRight", "Backspace", "ArrowLeft", "ArrowDown", "ArrowUp"} {
+		k, err := parseKeyName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, k)
+	}
+	for _, c := range []byte("ж") {
+		keys = append(keys, Key{Code: int(c)})
+	}
+	e.term = &Mock{line: keys}
+	for range keys {
+		if _, err := e.ProcessKeypress(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := string(e.rows[0].chars); got != "Пжрвет" || e.cursor.x != len("Пж") {
+		t.Fatalf("unexpected row %q with cursor %d", got, e.cursor.x)
+	}
+	e.editorScroll()
+	if e.rx != 2 {
+		t.Errorf("unexpected render column %d", e.rx)
+	}
+	var ab bytes.Buffer
+	e.editorDrawRows(&ab)
+	if lines := splitScreenLines(ab.Bytes()); len(lines[0]) != 6 || lines[0][1].ch != "ж" || len(lines[1]) != e.settings.tabStop+1 || lines[1][e.settings.tabStop].ch != "@" {
+		t.Errorf("unexpected drawn rows %q", ab.String())
+	}
+	out, err := e.Content()
+	if expect := "\xCF\xE6\xF0\xE2\xE5\xF2\n\t\x01\n"; err != nil || string(out) != expect {
+		t.Fatalf("content %q %v, expected %q", out, err, expect)
+	}
+}
+
+func TestLoadReplacesBuffer(t *testing.T) {
+	t.Parallel()
+	e := newTestEditor(t)
+	for _, content := range []string{"first\nsecond\n", "third\n"} {
+		e.cursor.y, e.cursor.x = 1, 3
+		if err := e.Load(strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if str, _ := e.editorRowsToString(); str != "third\n" || e.cursor.y != 0 || e.cursor.x != 0 {
+		t.Fatalf("unexpected buffer %q with cursor %d %d", str, e.cursor.y, e.cursor.x)
+	}
+}
+
+func TestStdout(t *testing.T) {
+	t.Parallel()
+	quit := func(e *Editor) {
+		t.Helper()
+		e.term = &Mock{line: []Key{{Code: 'q' & 0x1f}}}
+		if out, err := e.ProcessKeypress(); err != nil || !out {
+			t.Fatalf("not quit: %v %v", out, err)
+		}
+	}
+	input := []byte("caf\xe9\n\tline  \n")
+	e, err := New(Options{Stdout: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Load(bytes.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	quit(e)
+	if out, err := e.Content(); err != nil || !bytes.Equal(out, input) {
+		t.Fatalf("content %q %v, expected %q", out, err, input)
+	}
+
+	// unsaved changes are written without confirmation
+	if err := e.Load(bytes.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	e.InsertChar('!')
+	quit(e)
+	if out, err := e.Content(); err != nil || string(out) != "!caf\xe9\n\tline  \n" {
+		t.Fatalf("unexpected content %q %v", out, err)
+	}
+}
+
+func TestSuspend(t *testing.T) {
+	t.Parallel()
+	e := newTestEditor(t)
+	m := &Mock{line: []Key{{Code: 'z' & 0x1f}}}
+	e.term = m
+	e.drawn.valid = true
+	if _, err := e.ProcessKeypress(); err != nil {
+		t.Fatal(err)
+	}
+	if m.suspended != 1 {
+		t.Fatalf("terminal is not suspended")
+	}
+	if e.drawn.valid {
+		t.Fatalf("screen is not redrawn after resume")
+	}
+	if e.screen.rows != 98 || e.screen.cols != 100 {
+		t.Fatalf("window size is not updated: %d %d", e.screen.rows, e.screen.cols)
+	}
+}
+
+// rowsModel is the reference model of buffer for fuzz tests.
+type rowsModel struct {
+	lines []string
+	x, y  int
+}
+
+// checkModel compares the buffer and the cursor with model.
+func checkModel(t *testing.T, e *Editor, m *rowsModel, step int) {
+	t.Helper()
+	if len(e.rows) != len(m.lines) {
+		t.Fatalf("step %d: got %d rows, expected %d", step, len(e.rows), len(m.lines))
+	}
+	for i, row := range e.rows {
+		if string(row.chars) != m.lines[i] || row.size != len(row.chars) {
+			t.Fatalf("step %d: row %d is %q of size %d, expected %q",
+				step, i, row.chars, row.size, m.lines[i])
+		}
+		if rx := e.editorRowCxToRx(&e.rows[i], row.size); row.rsize != rx {
+			t.Fatalf("step %d: row %d has render size %d, expected %d", step, i, row.rsize, rx)
+		}
+	}
+	if e.cursor.x != m.x || e.cursor.y != m.y {
+		t.Fatalf("step %d: cursor %d %d, expected %d %d", step, e.cursor.y, e.cursor.x, m.y, m.x)
+	}
+}
+
+// checkSave compares the saved file with model.
+func checkSave(t *testing.T, e *Editor, m *rowsModel) {
+	t.Helper()
+	e.filename = filepath.Join(t.TempDir(), "file.txt")
+	if err := e.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if e.dirty {
+		t.Fatal(e.status.msg)
+	}
+	content, err := ioutil.ReadFile(e.filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := ""
+	for _, line := range m.lines {
+		expect += line + "\n"
+	}
+	if string(content) != expect {
+		t.Fatalf("saved %q, expected %q", content, expect)
+	}
+}
+
+func FuzzRowEditing(f *testing.F) {
+	f.Add([]byte{0, 0, 'a', 2, 0, 1, 'b', 1, 1, 0, 3, 0, 0, 4, 0, 5})
+	f.Add([]byte{0, 0, 'a', 1, 1, 0, 1, 0, 0, 3, 0, 9, 4, 0, 0})
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		e := newTestEditor(t)
+		var m rowsModel
+		for step := 0; len(ops) >= 3; step++ {
+			op, at, arg := ops[0]%5, int(int8(ops[1])), ops[2]
+			ops = ops[3:]
+			switch op {
+			case 0:
+				e.editorInsertRow(at, []byte{arg})
+				if 0 <= at && at <= len(m.lines) {
+					m.lines = append(m.lines[:at], append([]string{string([]byte{arg})}, m.lines[at:]...)...)
+				}
+			case 1:
+				e.editorDelRow(at)
+				if 0 <= at && at < len(m.lines) {
+					m.lines = append(m.lines[:at], m.lines[at+1:]...)
+				}
+			default:
+				// operations in row arg
+				y := int(arg)
+				if y >= len(m.lines) {
+					continue
+				}
+				row, line := &e.rows[y], m.lines[y]
+				switch op {
+				case 2:
+					e.editorRowInsertChar(row, at, 'c')
+					if at < 0 || at > len(line) {
+						at = len(line)
+					}
+					m.lines[y] = line[:at] + "c" + line[at:]
+				case 3:
+					e.editorRowDelChar(row, at)
+					if 0 <= at && at < len(line) {
+						m.lines[y] = line[:at] + line[at+1:]
+					}
+				case 4:
+					e.editorRowAppendString(row, []byte("\tz"))
+					m.lines[y] = line + "\tz"
+				}
+			}
+			checkModel(t, e, &m, step)
+		}
+		checkSave(t, e, &m)
+	})
+}
+
+// fuzzKeys are keys of fuzz tests by byte value less than 0x20. Other bytes
+// are typed characters.
+var fuzzKeys = []Key{
+	{Code: '\r'}, {Code: BACKSPACE}, {Code: DEL_KEY},
+	{Code: ARROW_LEFT}, {Code: ARROW_RIGHT}, {Code: ARROW_UP}, {Code: ARROW_DOWN},
+	{Code: HOME_KEY}, {Code: END_KEY}, {Code: PASTE_KEY, Text: "p\r\nq"},
+}
+
+// press applies the key to model.
+func (m *rowsModel) press(k Key) {
+	rowlen := func() int {
+		if m.y < len(m.lines) {
+			return len(m.lines[m.y])
+		}
+		return 0
+	}
+	insert := func(s string) {
+		if m.y == len(m.lines) {
+			m.lines = append(m.lines, "")
+		}
+		line := m.lines[m.y]
+		parts := strings.Split(line[:m.x]+s, "\n")
+		last := len(parts) - 1
+		x := len(parts[last])
+		parts[last] += line[m.x:]
+		m.lines = append(m.lines[:m.y], append(parts, m.lines[m.y+1:]...)...)
+		m.x, m.y = x, m.y+last
+	}
+	switch k.Code {
+	case '\r':
+		if m.x == 0 {
+			m.lines = append(m.lines[:m.y], append([]string{""}, m.lines[m.y:]...)...)
+		} else {
+			line := m.lines[m.y]
+			m.lines = append(m.lines[:m.y], append([]string{line[:m.x], line[m.x:]}, m.lines[m.y+1:]...)...)
+		}
+		m.y++
+		m.x = 0
+	case DEL_KEY:
+		m.press(Key{Code: ARROW_RIGHT})
+		m.press(Key{Code: BACKSPACE})
+	case BACKSPACE:
+		switch {
+		case m.y == len(m.lines), m.x == 0 && m.y == 0:
+		case m.x > 0:
+			line := m.lines[m.y]
+			_, size := utf8.DecodeLastRuneInString(line[:m.x])
+			m.lines[m.y] = line[:m.x-size] + line[m.x:]
+			m.x -= size
+		default:
+			m.x = len(m.lines[m.y-1])
+			m.lines[m.y-1] += m.lines[m.y]
+			m.lines = append(m.lines[:m.y], m.lines[m.y+1:]...)
+			m.y--
+		}
+	case ARROW_LEFT:
+		if m.x != 0 {
+			_, size := utf8.DecodeLastRuneInString(m.lines[m.y][:m.x])
+			m.x -= size
+		} else if m.y > 0 {
+			m.y--
+			m.x = rowlen()
+		}
+	case ARROW_RIGHT:
+		if m.y < len(m.lines) {
+			if m.x < rowlen() {
+				_, size := utf8.DecodeRuneInString(m.lines[m.y][m.x:])
+				m.x += size
+			} else {
+				m.y++
+				m.x = 0
+			}
+		}
+	case ARROW_UP:
+		if m.y > 0 {
+			m.y--
+		}
+	case ARROW_DOWN:
+		if m.y < len(m.lines) {
+			m.y++
+		}
+	case HOME_KEY:
+		m.x = 0
+	case END_KEY:
+		m.x = rowlen()
+	case PASTE_KEY:
+		insert(strings.Replace(k.Text, "\r\n", "\n", -1))
+	default:
+		insert(string(rune(k.Code)))
+	}
+	if m.x > rowlen() {
+		m.x = rowlen()
+	}
+	for m.x < rowlen() && !utf8.RuneStart(m.lines[m.y][m.x]) {
+		m.x--
+	}
+}
+
+func FuzzKeys(f *testing.F) {
+	f.Add([]byte("one\ntwo\n"), []byte("ab\x00\x01\x03\x00\x09\x05\x08cd\x02\x02\x06"))
+	f.Add([]byte(""), []byte("\x00\x00x\x05\x01\x01\x04\x04\x02"))
+	f.Add([]byte("a\tb\r\n\nc"), []byte("\x06\x06\x08\x00z\x09\x01\x01\x01\x05\x02\x02"))
+	f.Fuzz(func(t *testing.T, content, keys []byte) {
+		if !utf8.Valid(content) || bytes.IndexByte(content, 0) >= 0 || bytes.HasPrefix(content, []byte("\xef\xbb\xbf")) {
+			return
+		}
+		e := newTestEditor(t)
+		e.screen.rows, e.screen.cols = 5, 10
+		e.out = ioutil.Discard
+		if err := e.Load(bytes.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+		var m rowsModel
+		for _, line := range strings.SplitAfter(string(content), "\n") {
+			if line != "" {
+				m.lines = append(m.lines, strings.TrimRight(strings.TrimSuffix(line, "\n"), "\r"))
+			}
+		}
+		checkModel(t, e, &m, 0)
+
+		var mock Mock
+		for _, b := range keys {
+			switch {
+			case int(b) < len(fuzzKeys):
+				mock.line = append(mock.line, fuzzKeys[b])
+			case 0x20 <= b && b < 0x7f:
+				mock.line = append(mock.line, Key{Code: int(b)})
+			default:
+				mock.line = append(mock.line, Key{Code: '\t'})
+			}
+		}
+		e.term = &mock
+		for step, k := range mock.line {
+			if _, err := e.ProcessKeypress(); err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Refresh(); err != nil {
+				t.Fatal(err)
+			}
+			m.press(k)
+			checkModel(t, e, &m, step+1)
+		}
+		checkSave(t, e, &m)
+	})
+}
+
+// ShowDiff will print two strings vertically next to each other so that line
+// differences are easier to read.
+func ShowDiff(a, b string) string {
+	aLines := strings.Split(a, "\n")
+	bLines := strings.Split(b, "\n")
+	maxLines := int(math.Max(float64(len(aLines)), float64(len(bLines))))
+	out := "\n"
+
+	for lineNumber := 0; lineNumber < maxLines; lineNumber++ {
+		aLine := ""
+		bLine := ""
+
+		// Replace NULL characters with a dot. Otherwise the strings will look
+		// exactly the same but have different length (and therfore not be
+		// equal).
+		if lineNumber < len(aLines) {
+			aLine = strconv.Quote(aLines[lineNumber])
+		}
+		if lineNumber < len(bLines) {
+			bLine = strconv.Quote(bLines[lineNumber])
+		}
+
+		diffFlag := " "
+		if aLine != bLine {
+			diffFlag = "*"
+		}
+		out += fmt.Sprintf("%s %3d %-40s%s\n", diffFlag, lineNumber+1, aLine, bLine)
+
+		if lineNumber > len(aLines) || lineNumber > len(bLines) {
+			out += "and more other ..."
+			break
+		}
+	}
+
+	return out
+}
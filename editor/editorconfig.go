@@ -0,0 +1,273 @@
+package editor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// editorconfig
+//
+// EditorConfig files ".editorconfig" (https://editorconfig.org) in
+// directory of file and in its parents up to the file with "root = true"
+// set the indentation, line ending, charset and whitespace of file:
+//
+//	indent_style             = tab | space
+//	indent_size              = <number> | tab
+//	tab_width                = <number>
+//	end_of_line              = lf | crlf | cr
+//	charset                  = latin1 | utf-8 | utf-8-bom | utf-16be | utf-16le
+//	trim_trailing_whitespace = true | false
+//	insert_final_newline     = true | false
+//
+// Sections of nearer file and later sections override properties, value
+// "unset" removes the property. Unknown properties and values are ignored.
+// EditorConfig is applied over user config and under project config files.
+
+const EDITORCONFIG_FILE = ".editorconfig"
+
+// editorConfigSection is the section of EditorConfig file with glob.
+type editorConfigSection struct {
+	glob  *regexp.Regexp
+	props [][2]string // name and value
+}
+
+// editorConfigFile is the parsed EditorConfig file.
+type editorConfigFile struct {
+	root     bool
+	dir      string // directory of file, globs are relative to it
+	sections []editorConfigSection
+}
+
+// parseEditorConfig parses the EditorConfig file of directory dir. Names
+// and values are in lower case.
+func parseEditorConfig(r io.Reader, dir string) (*editorConfigFile, error) {
+	c := &editorConfigFile{dir: dir}
+	var section *editorConfigSection
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' || text[0] == ';' {
+			continue
+		}
+		if text[0] == '[' && text[len(text)-1] == ']' {
+			glob := text[1 : len(text)-1]
+			if strings.Contains(glob, "/") {
+				glob = strings.TrimPrefix(glob, "/")
+			} else {
+				glob = "**/" + glob
+			}
+			re, err := regexp.Compile("^" + globToRegexp(glob) + "$")
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: bad glob %q", filepath.Join(dir, EDITORCONFIG_FILE), line, text)
+			}
+			c.sections = append(c.sections, editorConfigSection{glob: re})
+			section = &c.sections[len(c.sections)-1]
+			continue
+		}
+		eq := strings.IndexAny(text, "=:")
+		if eq < 0 {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(text[:eq]))
+		value := strings.ToLower(strings.TrimSpace(text[eq+1:]))
+		switch {
+		case section != nil:
+			section.props = append(section.props, [2]string{name, value})
+		case name == "root":
+			c.root = value == "true"
+		}
+	}
+	return c, scanner.Err()
+}
+
+// globToRegexp converts the glob of EditorConfig section into regular
+// expression: "*" is any string without "/", "**" is any string, "?" is
+// any character, "[abc]" and "[!abc]" are sets of characters, "{a,b}" are
+// alternatives and "{1..10}" are numbers.
+func globToRegexp(glob string) string {
+	var re strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				re.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(glob[i:], "**") {
+				re.WriteString(".*")
+				i++
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				break
+			}
+			set := glob[i+1 : i+1+end]
+			re.WriteByte('[')
+			if strings.HasPrefix(set, "!") {
+				re.WriteByte('^')
+				set = set[1:]
+			}
+			re.WriteString(strings.NewReplacer(`\`, `\\`, `[`, `\[`, `^`, `\^`).Replace(set))
+			re.WriteByte(']')
+			i += end + 1
+		case '{':
+			end := strings.IndexByte(glob[i+1:], '}')
+			if end < 0 {
+				re.WriteString(`\{`)
+				break
+			}
+			re.WriteString(braceToRegexp(glob[i+1 : i+1+end]))
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				re.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return re.String()
+}
+
+// editorConfigRange are numbers of brace "{1..10}"
+var editorConfigRange = regexp.MustCompile(`^([+-]?\d+)\.\.([+-]?\d+)$`)
+
+// braceToRegexp converts the content of braces of glob.
+func braceToRegexp(s string) string {
+	if m := editorConfigRange.FindStringSubmatch(s); m != nil {
+		from, _ := strconv.Atoi(m[1])
+		to, _ := strconv.Atoi(m[2])
+		if from > to {
+			from, to = to, from
+		}
+		if to-from > 1000 {
+			return `[+-]?\d+`
+		}
+		var alts []string
+		for n := from; n <= to; n++ {
+			alts = append(alts, strconv.Itoa(n))
+		}
+		return "(?:" + strings.Join(alts, "|") + ")"
+	}
+	if !strings.Contains(s, ",") {
+		return regexp.QuoteMeta("{" + s + "}")
+	}
+	var alts []string
+	for _, alt := range strings.Split(s, ",") {
+		alts = append(alts, globToRegexp(alt))
+	}
+	return "(?:" + strings.Join(alts, "|") + ")"
+}
+
+// editorConfigProperties returns the properties of EditorConfig files for
+// the file.
+func editorConfigProperties(filename string) (map[string]string, error) {
+	filename, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	var files []*editorConfigFile
+	for dir := filepath.Dir(filename); ; {
+		f, err := os.Open(filepath.Join(dir, EDITORCONFIG_FILE))
+		if err == nil {
+			c, err := parseEditorConfig(f, dir)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+			files = append([]*editorConfigFile{c}, files...)
+			if c.root {
+				break
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	props := map[string]string{}
+	for _, c := range files {
+		rel, err := filepath.Rel(c.dir, filename)
+		if err != nil {
+			return nil, err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, s := range c.sections {
+			if !s.glob.MatchString(rel) {
+				continue
+			}
+			for _, p := range s.props {
+				if p[1] == "unset" {
+					delete(props, p[0])
+				} else {
+					props[p[0]] = p[1]
+				}
+			}
+		}
+	}
+	return props, nil
+}
+
+// setEditorConfig sets the properties of EditorConfig. Unknown values are
+// ignored.
+func (s *settings) setEditorConfig(props map[string]string) {
+	number := func(name string) (int, bool) {
+		v, err := strconv.Atoi(props[name])
+		return v, err == nil && v > 0
+	}
+	switch props["indent_style"] {
+	case "tab":
+		s.expandTab = false
+	case "space":
+		s.expandTab = true
+	}
+	if v, ok := number("indent_size"); ok {
+		s.indentSize = v
+		s.tabStop = v
+	} else if props["indent_size"] == "tab" {
+		s.indentSize = 0
+	}
+	if v, ok := number("tab_width"); ok {
+		s.tabStop = v
+	}
+	switch props["end_of_line"] {
+	case "lf":
+		s.lineEnding = "\n"
+	case "crlf":
+		s.lineEnding = "\r\n"
+	case "cr":
+		s.lineEnding = "\r"
+	}
+	switch charset := props["charset"]; charset {
+	case "latin1", "utf-8", "utf-8-bom", "utf-16be", "utf-16le":
+		s.charset = charset
+	}
+	switch props["trim_trailing_whitespace"] {
+	case "true":
+		s.trimTrailingSpace = true
+	case "false":
+		s.trimTrailingSpace = false
+	}
+	switch props["insert_final_newline"] {
+	case "true":
+		s.finalNewline = true
+	case "false":
+		s.finalNewline = false
+	}
+}
@@ -0,0 +1,151 @@
+package editor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestEditorConfigGlob(t *testing.T) {
+	tcs := []struct {
+		glob  string
+		match []string
+		not   []string
+	}{
+		{"**/*.go", []string{"main.go", "a/b/main.go"}, []string{"main.c", "main.go/x"}},
+		{"lib/**.js", []string{"lib/a.js", "lib/a/b.js"}, []string{"a.js", "src/lib/a.js"}},
+		{"*.{c,h}", []string{"a.c", "a.h"}, []string{"a.ch", "a/b.c"}},
+		{"f?le[0-9].txt", []string{"file1.txt", "fale9.txt"}, []string{"file.txt", "fi/e1.txt"}},
+		{"[!a]*", []string{"b", "ba"}, []string{"a", "ab"}},
+		{"v{1..12}", []string{"v1", "v12"}, []string{"v0", "v13"}},
+		{"{a}[b", []string{"{a}[b"}, []string{"a[b"}},
+		{`\*.md`, []string{"*.md"}, []string{"a.md"}},
+	}
+	for _, tc := range tcs {
+		re, err := regexp.Compile("^" + globToRegexp(tc.glob) + "$")
+		if err != nil {
+			t.Errorf("%s: %v", tc.glob, err)
+			continue
+		}
+		for _, name := range tc.match {
+			if !re.MatchString(name) {
+				t.Errorf("%s: %q is not matched by %s", tc.glob, name, re)
+			}
+		}
+		for _, name := range tc.not {
+			if re.MatchString(name) {
+				t.Errorf("%s: %q is matched by %s", tc.glob, name, re)
+			}
+		}
+	}
+}
+
+func TestEditorConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+
+	write := func(name, content string) {
+		t.Helper()
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(EDITORCONFIG_FILE, "[*]\nindent_style = tab\n")
+	write("project/"+EDITORCONFIG_FILE, "root = true\n\n[*]\nend_of_line = lf\ninsert_final_newline = true\n"+
+		"[*.go]\nindent_style = tab\nindent_size = 8\n\n[*.{txt,md}]\nCharset = latin1\nend_of_line = CRLF\n"+
+		"trim_trailing_whitespace = true\n[docs/**]\ninsert_final_newline = false\nindent_style = space\nindent_size = 2\n")
+	write("project/docs/"+EDITORCONFIG_FILE, "; comment\n[*.txt]\nindent_size = unset\ntab_width = 3\n")
+
+	tcs := []struct {
+		file  string
+		props map[string]string
+	}{
+		{"project/main.go", map[string]string{
+			"end_of_line": "lf", "insert_final_newline": "true", "indent_style": "tab", "indent_size": "8",
+		}},
+		{"project/docs/a.txt", map[string]string{
+			"end_of_line": "crlf", "insert_final_newline": "false", "charset": "latin1",
+			"trim_trailing_whitespace": "true", "indent_style": "space", "tab_width": "3",
+		}},
+	}
+	for _, tc := range tcs {
+		props, err := editorConfigProperties(filepath.Join(dir, tc.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(props, tc.props) {
+			t.Errorf("%s: got %v, expected %v", tc.file, props, tc.props)
+		}
+	}
+
+	// settings are applied to buffer and to saved file, indent size is
+	// tab width
+	filename := filepath.Join(dir, "project", "docs", "a.txt")
+	write("project/docs/a.txt", "caf\xe9  \r\n\tx\t\r\n")
+	e, err := New(Options{Config: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Open(filename); err != nil {
+		t.Fatal(err)
+	}
+	if e.encoding != "latin1" || e.settings.tabStop != 3 || !e.settings.expandTab {
+		t.Fatalf("unexpected settings: %s %+v", e.encoding, e.settings)
+	}
+	if e.rows[1].rsize != 6 {
+		t.Errorf("unexpected render %q", e.rows[1].render)
+	}
+	e.cursor.y, e.cursor.x = 1, 1
+	e.term = &Mock{line: []Key{{Code: '\t'}}}
+	if _, err := e.ProcessKeypress(); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(e.rows[1].chars); got != "\t   x\t" {
+		t.Errorf("unexpected indent: %q", got)
+	}
+	if err := e.Save(); err != nil || e.dirty {
+		t.Fatal(err, e.status.msg)
+	}
+	if b, _ := ioutil.ReadFile(filename); string(b) != "caf\xe9\r\n\t   x" {
+		t.Errorf("unexpected saved file: %q", b)
+	}
+
+	// charset of file is used for valid UTF-8 content too
+	filename = filepath.Join(dir, "project", "c.md")
+	write("project/c.md", "\xc3\xa9\r\n")
+	if e, err = New(Options{Config: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Open(filename); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(e.rows[0].chars); e.encoding != "latin1" || got != "\u00c3\u00a9" {
+		t.Errorf("unexpected row %q in %s", got, e.encoding)
+	}
+	if err := e.Save(); err != nil || e.dirty {
+		t.Fatal(err, e.status.msg)
+	}
+	if b, _ := ioutil.ReadFile(filename); string(b) != "\xc3\xa9\r\n" {
+		t.Errorf("unexpected saved file: %q", b)
+	}
+
+	// not configured file is not changed
+	write("other/b.txt", "a \n")
+	if err := e.Open(filepath.Join(dir, "other", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if e.settings.expandTab || e.settings.trimTrailingSpace || e.settings.lineEnding != "\n" {
+		t.Errorf("unexpected settings: %+v", e.settings)
+	}
+}
@@ -0,0 +1,241 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// encodings
+//
+// The buffer is always edited as UTF-8. On open the encoding of file is
+// detected by BOM (UTF-8, UTF-16LE, UTF-16BE), then by UTF-8 validity and
+// at the end the legacy single byte encoding Options.Encoding is used. On
+// save the buffer is converted back into encoding of the file.
+
+type encoding struct {
+	name   string
+	bom    []byte
+	legacy bool // single byte encoding
+	decode func(b []byte) []byte
+	encode func(b []byte) ([]byte, error)
+}
+
+const DEFAULT_ENCODING = "utf-8"
+
+// DEFAULT_LEGACY_ENCODING is encoding of files, that are not valid UTF-8
+const DEFAULT_LEGACY_ENCODING = "latin1"
+
+var encodings = map[string]encoding{
+	"utf-8": {
+		name:   "utf-8",
+		decode: func(b []byte) []byte { return b },
+		encode: func(b []byte) ([]byte, error) { return b, nil },
+	},
+	"utf-8-bom": {
+		name:   "utf-8-bom",
+		bom:    []byte{0xEF, 0xBB, 0xBF},
+		decode: func(b []byte) []byte { return b },
+		encode: func(b []byte) ([]byte, error) { return b, nil },
+	},
+	"utf-16le": {
+		name:   "utf-16le",
+		bom:    []byte{0xFF, 0xFE},
+		decode: func(b []byte) []byte { return decodeUTF16(b, false) },
+		encode: func(b []byte) ([]byte, error) { return encodeUTF16(b, false), nil },
+	},
+	"utf-16be": {
+		name:   "utf-16be",
+		bom:    []byte{0xFE, 0xFF},
+		decode: func(b []byte) []byte { return decodeUTF16(b, true) },
+		encode: func(b []byte) ([]byte, error) { return encodeUTF16(b, true), nil },
+	},
+	"latin1":       charmapEncoding("latin1", nil),
+	"windows-1251": charmapEncoding("windows-1251", &windows1251),
+	"windows-1252": charmapEncoding("windows-1252", &windows1252),
+	"koi8-r":       charmapEncoding("koi8-r", &koi8r),
+}
+
+// EncodingNames returns sorted names of all supported encodings.
+func EncodingNames() (names []string) {
+	for name := range encodings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// LegacyEncodingNames returns sorted names of single byte encodings, that
+// may be used as Options.Encoding.
+func LegacyEncodingNames() (names []string) {
+	for name, enc := range encodings {
+		if enc.legacy {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+// detectEncoding returns encoding of content and content without BOM.
+// If content is not valid UTF-8, then legacy encoding is used.
+func detectEncoding(content []byte, legacy string) (encoding, []byte) {
+	for _, name := range []string{"utf-8-bom", "utf-16le", "utf-16be"} {
+		enc := encodings[name]
+		if bytes.HasPrefix(content, enc.bom) {
+			return enc, content[len(enc.bom):]
+		}
+	}
+	if utf8.Valid(content) {
+		return encodings[DEFAULT_ENCODING], content
+	}
+	if enc, ok := encodings[strings.ToLower(legacy)]; ok && enc.legacy {
+		return enc, content
+	}
+	return encodings[DEFAULT_LEGACY_ENCODING], content
+}
+
+// encodeBuffer converts UTF-8 content into encoding with BOM.
+func encodeBuffer(content []byte, name string) ([]byte, error) {
+	enc, ok := encodings[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	b, err := enc.encode(content)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, enc.bom...), b...), nil
+}
+
+// decodeUTF16 decodes UTF-16 content. The odd last byte is not decoded,
+// so such content is kept as binary, see Load.
+func decodeUTF16(b []byte, bigEndian bool) []byte {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		if bigEndian {
+			u[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+		} else {
+			u[i] = uint16(b[2*i+1])<<8 | uint16(b[2*i])
+		}
+	}
+	return []byte(string(utf16.Decode(u)))
+}
+
+func encodeUTF16(b []byte, bigEndian bool) []byte {
+	u := utf16.Encode([]rune(string(b)))
+	out := make([]byte, 0, 2*len(u))
+	for _, c := range u {
+		if bigEndian {
+			out = append(out, byte(c>>8), byte(c))
+		} else {
+			out = append(out, byte(c), byte(c>>8))
+		}
+	}
+	return out
+}
+
+// charmapEncoding returns single byte encoding. Bytes 0x00-0x7F are ASCII,
+// bytes 0x80-0xFF are mapped by table high. Nil table is Latin-1.
+func charmapEncoding(name string, high *[128]rune) encoding {
+	decodeByte := func(c byte) rune {
+		if c < 0x80 || high == nil {
+			return rune(c)
+		}
+		return high[c-0x80]
+	}
+	return encoding{
+		name:   name,
+		legacy: true,
+		decode: func(b []byte) []byte {
+			out := make([]byte, 0, len(b))
+			for _, c := range b {
+				out = append(out, string(decodeByte(c))...)
+			}
+			return out
+		},
+		encode: func(b []byte) ([]byte, error) {
+			reverse := map[rune]byte{}
+			for c := 0x80; c <= 0xFF; c++ {
+				reverse[decodeByte(byte(c))] = byte(c)
+			}
+			out := make([]byte, 0, len(b))
+			line := 1
+			for _, r := range string(b) {
+				if r < 0x80 {
+					if r == '\n' {
+						line++
+					}
+					out = append(out, byte(r))
+					continue
+				}
+				c, ok := reverse[r]
+				if !ok {
+					return nil, fmt.Errorf("character %q at line %d cannot be encoded in %s", r, line, name)
+				}
+				out = append(out, c)
+			}
+			return out, nil
+		},
+	}
+}
+
+var windows1251 = [128]rune{
+	0x0402, 0x0403, 0x201A, 0x0453, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x20AC, 0x2030, 0x0409, 0x2039, 0x040A, 0x040C, 0x040B, 0x040F,
+	0x0452, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x0098, 0x2122, 0x0459, 0x203A, 0x045A, 0x045C, 0x045B, 0x045F,
+	0x00A0, 0x040E, 0x045E, 0x0408, 0x00A4, 0x0490, 0x00A6, 0x00A7,
+	0x0401, 0x00A9, 0x0404, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x0407,
+	0x00B0, 0x00B1, 0x0406, 0x0456, 0x0491, 0x00B5, 0x00B6, 0x00B7,
+	0x0451, 0x2116, 0x0454, 0x00BB, 0x0458, 0x0405, 0x0455, 0x0457,
+	0x0410, 0x0411, 0x0412, 0x0413, 0x0414, 0x0415, 0x0416, 0x0417,
+	0x0418, 0x0419, 0x041A, 0x041B, 0x041C, 0x041D, 0x041E, 0x041F,
+	0x0420, 0x0421, 0x0422, 0x0423, 0x0424, 0x0425, 0x0426, 0x0427,
+	0x0428, 0x0429, 0x042A, 0x042B, 0x042C, 0x042D, 0x042E, 0x042F,
+	0x0430, 0x0431, 0x0432, 0x0433, 0x0434, 0x0435, 0x0436, 0x0437,
+	0x0438, 0x0439, 0x043A, 0x043B, 0x043C, 0x043D, 0x043E, 0x043F,
+	0x0440, 0x0441, 0x0442, 0x0443, 0x0444, 0x0445, 0x0446, 0x0447,
+	0x0448, 0x0449, 0x044A, 0x044B, 0x044C, 0x044D, 0x044E, 0x044F,
+}
+
+var windows1252 = [128]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+	0x00A0, 0x00A1, 0x00A2, 0x00A3, 0x00A4, 0x00A5, 0x00A6, 0x00A7,
+	0x00A8, 0x00A9, 0x00AA, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x00AF,
+	0x00B0, 0x00B1, 0x00B2, 0x00B3, 0x00B4, 0x00B5, 0x00B6, 0x00B7,
+	0x00B8, 0x00B9, 0x00BA, 0x00BB, 0x00BC, 0x00BD, 0x00BE, 0x00BF,
+	0x00C0, 0x00C1, 0x00C2, 0x00C3, 0x00C4, 0x00C5, 0x00C6, 0x00C7,
+	0x00C8, 0x00C9, 0x00CA, 0x00CB, 0x00CC, 0x00CD, 0x00CE, 0x00CF,
+	0x00D0, 0x00D1, 0x00D2, 0x00D3, 0x00D4, 0x00D5, 0x00D6, 0x00D7,
+	0x00D8, 0x00D9, 0x00DA, 0x00DB, 0x00DC, 0x00DD, 0x00DE, 0x00DF,
+	0x00E0, 0x00E1, 0x00E2, 0x00E3, 0x00E4, 0x00E5, 0x00E6, 0x00E7,
+	0x00E8, 0x00E9, 0x00EA, 0x00EB, 0x00EC, 0x00ED, 0x00EE, 0x00EF,
+	0x00F0, 0x00F1, 0x00F2, 0x00F3, 0x00F4, 0x00F5, 0x00F6, 0x00F7,
+	0x00F8, 0x00F9, 0x00FA, 0x00FB, 0x00FC, 0x00FD, 0x00FE, 0x00FF,
+}
+
+var koi8r = [128]rune{
+	0x2500, 0x2502, 0x250C, 0x2510, 0x2514, 0x2518, 0x251C, 0x2524,
+	0x252C, 0x2534, 0x253C, 0x2580, 0x2584, 0x2588, 0x258C, 0x2590,
+	0x2591, 0x2592, 0x2593, 0x2320, 0x25A0, 0x2219, 0x221A, 0x2248,
+	0x2264, 0x2265, 0x00A0, 0x2321, 0x00B0, 0x00B2, 0x00B7, 0x00F7,
+	0x2550, 0x2551, 0x2552, 0x0451, 0x2553, 0x2554, 0x2555, 0x2556,
+	0x2557, 0x2558, 0x2559, 0x255A, 0x255B, 0x255C, 0x255D, 0x255E,
+	0x255F, 0x2560, 0x2561, 0x0401, 0x2562, 0x2563, 0x2564, 0x2565,
+	0x2566, 0x2567, 0x2568, 0x2569, 0x256A, 0x256B, 0x256C, 0x00A9,
+	0x044E, 0x0430, 0x0431, 0x0446, 0x0434, 0x0435, 0x0444, 0x0433,
+	0x0445, 0x0438, 0x0439, 0x043A, 0x043B, 0x043C, 0x043D, 0x043E,
+	0x043F, 0x044F, 0x0440, 0x0441, 0x0442, 0x0443, 0x0436, 0x0432,
+	0x044C, 0x044B, 0x0437, 0x0448, 0x044D, 0x0449, 0x0447, 0x044A,
+	0x042E, 0x0410, 0x0411, 0x0426, 0x0414, 0x0415, 0x0424, 0x0413,
+	0x0425, 0x0418, 0x0419, 0x041A, 0x041B, 0x041C, 0x041D, 0x041E,
+	0x041F, 0x042F, 0x0420, 0x0421, 0x0422, 0x0423, 0x0416, 0x0412,
+	0x042C, 0x042B, 0x0417, 0x0428, 0x042D, 0x0429, 0x0427, 0x042A,
+}
@@ -0,0 +1,62 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestEncoding(t *testing.T) {
+	tcs := []struct {
+		name   string
+		legacy string
+		input  []byte
+		utf8   string
+	}{
+		{"utf-8", "", []byte("Привет\n"), "Привет\n"},
+		{"utf-8-bom", "", []byte("\xEF\xBB\xBFabc\n"), "abc\n"},
+		{"utf-16le", "", []byte("\xFF\xFEa\x00\x1F\x04\n\x00"), "aП\n"},
+		{"utf-16be", "", []byte("\xFE\xFF\x00a\x04\x1F\x00\n"), "aП\n"},
+		{"windows-1251", "windows-1251", []byte("\xCF\xF0\xE8\xE2\xE5\xF2\n"), "Привет\n"},
+		{"koi8-r", "KOI8-R", []byte("\xF0\xD2\xC9\xD7\xC5\xD4\n"), "Привет\n"},
+		{"latin1", "unknown", []byte("caf\xE9\n"), "café\n"},
+	}
+	for i, tc := range tcs {
+		t.Run(fmt.Sprintf("%d:%s", i, tc.name), func(t *testing.T) {
+			enc, content := detectEncoding(tc.input, tc.legacy)
+			if enc.name != tc.name {
+				t.Fatalf("detected %s, expected %s", enc.name, tc.name)
+			}
+			if got := string(enc.decode(content)); got != tc.utf8 {
+				t.Fatalf("decoded %q, expected %q", got, tc.utf8)
+			}
+			out, err := encodeBuffer([]byte(tc.utf8), enc.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, tc.input) {
+				t.Fatalf("encoded %q, expected %q", out, tc.input)
+			}
+		})
+	}
+
+	if _, err := encodeBuffer([]byte("Привет"), "windows-1252"); err == nil {
+		t.Fatalf("expected error for not encoded characters")
+	}
+	if enc, _ := detectEncoding([]byte("caf\xE9\n"), "utf-16le"); enc.name != DEFAULT_LEGACY_ENCODING {
+		t.Fatalf("not legacy encoding %s is used", enc.name)
+	}
+	if _, err := New(Options{Encoding: "utf-16be"}); err == nil {
+		t.Fatalf("expected error for not legacy encoding")
+	}
+
+	// odd UTF-16 content is saved byte-for-byte
+	e := newTestEditor(t)
+	input := []byte("\xFF\xFEa\x00b")
+	if err := e.Load(bytes.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := e.Content(); err != nil || !bytes.Equal(out, input) {
+		t.Fatalf("content %q %v, expected %q", out, err, input)
+	}
+}
@@ -0,0 +1,115 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// headless
+//
+// Headless mode runs the key script against the file without terminal:
+// screen is not shown, waits of script only move the clock of editor. File
+// is saved after the script and any error stops the script: the failed
+// "expect-line", the error of editor or of save.
+
+// HEADLESS_ROWS and HEADLESS_COLS are window size of script without
+// "size" command
+const (
+	HEADLESS_ROWS = 24
+	HEADLESS_COLS = 80
+)
+
+// errEndOfScript is returned by read of key after the last key of script.
+var errEndOfScript = errors.New("end of key script")
+
+// headless is the terminal, that reads keys of key script.
+type headless struct {
+	e          *Editor
+	script     *KeyScript
+	pos        int
+	rows, cols int
+	mouse      bool
+	clock      time.Time
+	onRead     func(pos int) // called before reading of key
+}
+
+func newHeadless(e *Editor, s *KeyScript) *headless {
+	h := &headless{e: e, script: s, rows: HEADLESS_ROWS, cols: HEADLESS_COLS, clock: time.Now()}
+	if s.size.rows != 0 {
+		h.rows, h.cols = s.size.rows, s.size.cols
+	}
+	return h
+}
+
+func (h *headless) ReadKey() (Key, error) {
+	h.clock = h.clock.Add(h.script.waits[h.pos])
+	if h.onRead != nil {
+		h.onRead(h.pos)
+	}
+	if err := h.script.check(h.e, h.pos); err != nil {
+		return Key{}, err
+	}
+	if h.pos >= len(h.script.keys) {
+		return Key{}, errEndOfScript
+	}
+	k := h.script.keys[h.pos]
+	if r, ok := h.script.resizes[h.pos]; ok {
+		h.rows, h.cols = r.rows, r.cols
+	}
+	h.pos++
+	return k, nil
+}
+
+func (h *headless) WindowSize() (rows, cols int, err error) {
+	return h.rows, h.cols, nil
+}
+
+func (h *headless) EnableMouse(enable bool) error {
+	h.mouse = enable
+	return nil
+}
+
+func (h *headless) Suspend() error {
+	return nil
+}
+
+func (h *headless) now() time.Time {
+	return h.clock
+}
+
+// runScript runs the editor with terminal t, that reads keys of h, and
+// saves the modified buffer.
+func (e *Editor) runScript(h *headless, t Terminal, out io.Writer) error {
+	e.now = h.now
+	if err := e.Run(t, out); err != nil && err != errEndOfScript {
+		return err
+	}
+	// expectations after the last key
+	if h.pos == len(h.script.keys) {
+		if err := h.script.check(e, h.pos); err != nil {
+			return err
+		}
+	}
+	switch {
+	case !e.dirty:
+	case e.filename != "":
+		if err := e.Save(); err != nil {
+			return err
+		}
+		if e.dirty {
+			return fmt.Errorf("%s", e.status.msg)
+		}
+	case !e.options.Stdout:
+		return fmt.Errorf("cannot save buffer without filename")
+	}
+	return nil
+}
+
+// RunScript runs the key script against the buffer without terminal.
+func (e *Editor) RunScript(s *KeyScript) error {
+	h := newHeadless(e, s)
+	return e.runScript(h, h, ioutil.Discard)
+}
@@ -0,0 +1,77 @@
+package editor
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHeadless(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		name   string
+		script string
+		file   string
+		err    string
+	}{
+		{
+			name:   "typed text is saved",
+			script: "key End\ntype \"\\n\\nfunc main() {}\"\nexpect-line 3 \"func main() {}\"\n",
+			file:   "package main\n\nfunc main() {}\n",
+		},
+		{
+			name:   "saved before quit",
+			script: "type \"// \"\nkey Ctrl-S\nwait 10s\nkey Ctrl-Q\n",
+			file:   "// package main\n",
+		},
+		{
+			name:   "failed expectation",
+			script: "type \"x\"\nexpect-line 1 \"package main\"\ntype \"y\"\n",
+			file:   "package main\n",
+			err:    `line 2: line 1 is "xpackage main", expected "package main"`,
+		},
+		{
+			name:   "expectation after the last key",
+			script: "key Ctrl-Q\nexpect-line 2 \"\"\n",
+			file:   "package main\n",
+			err:    "line 2: expected line 2",
+		},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s, err := ParseKeyScript(strings.NewReader(tc.script))
+			if err != nil {
+				t.Fatal(err)
+			}
+			filename := filepath.Join(t.TempDir(), "main.go")
+			if err := ioutil.WriteFile(filename, []byte("package main\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			e := newTestEditor(t)
+			if err := e.Open(filename); err != nil {
+				t.Fatal(err)
+			}
+			err = e.RunScript(s)
+			if tc.err == "" && err != nil || tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Fatalf("got error %v, expected %q", err, tc.err)
+			}
+			content, err := ioutil.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tc.file {
+				t.Error(ShowDiff(tc.file, string(content)))
+			}
+		})
+	}
+
+	// buffer without filename
+	e := newTestEditor(t)
+	s, _ := ParseKeyScript(strings.NewReader("type \"x\"\n"))
+	if err := e.RunScript(s); err == nil {
+		t.Errorf("expected error of save without filename")
+	}
+}
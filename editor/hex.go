@@ -0,0 +1,245 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// hex view
+//
+// Hex view shows and edits the raw bytes of the buffer:
+//
+//	00000010  48 65 6c 6c 6f 20 77 6f  72 6c 64 0a 00 01 02 03  |Hello world.....|
+//
+// Hex digits change the byte under cursor, Tab switches between overwrite
+// and insert of bytes. Buffer saved in hex view is written byte-for-byte.
+
+const (
+	HEX_BYTES_PER_ROW = 16
+	HEX_ADDRESS_WIDTH = 10 // "00000010  "
+)
+
+type hexView struct {
+	enable bool
+	data   []byte // raw content of buffer
+	cursor int    // offset of byte under cursor
+	nibble int    // 0 - high, 1 - low half of byte under cursor
+	insert bool   // insert bytes, else overwrite
+	offset int    // first shown row
+}
+
+// editorToggleHex switches between text and hex view of the buffer.
+func (e *Editor) editorToggleHex() {
+	if !e.hex.enable {
+		data, err := e.Content()
+		if err != nil {
+			e.editorSetStatusMessage("Cannot show hex view: %v", err)
+			return
+		}
+		e.hex.data = data
+		e.hex.cursor = 0
+		e.hex.nibble = 0
+		e.hex.offset = 0
+		e.hex.enable = true
+		msg := "HEX: 0-9a-f = change byte | Tab = insert/overwrite"
+		if keys := e.keymap.keysOf("toggle-hex"); keys != "" {
+			msg += " | " + keys + " = text view"
+		}
+		e.editorSetStatusMessage("%s", msg)
+		return
+	}
+
+	// return to text view
+	dirty := e.dirty
+	data := e.hex.data
+	e.hex = hexView{}
+	e.rows = nil
+	e.cursor.x, e.cursor.y = 0, 0
+	e.offset.row, e.offset.col = 0, 0
+	enc := encodings[e.encoding]
+	e.editorSetContent(enc.decode(bytes.TrimPrefix(data, enc.bom)))
+	// content is raw data until the text is edited
+	e.raw = data
+	e.dirty, e.edited = dirty, false
+}
+
+// Content returns the bytes of buffer as they are written on save.
+func (e *Editor) Content() ([]byte, error) {
+	if e.hex.enable {
+		return e.hex.data, nil
+	}
+	if e.raw != nil && !e.edited {
+		return e.raw, nil
+	}
+	return encodeBuffer([]byte(e.editorRowsToFile()), e.encoding)
+}
+
+// hexCommands are commands, that run in hex view. Other commands change
+// the text rows, so their keys are ignored in hex view.
+var hexCommands = map[string]bool{
+	"none":         true,
+	"toggle-hex":   true,
+	"save":         true,
+	"quit":         true,
+	"suspend":      true,
+	"redraw":       true,
+	"describe-key": true,
+	"command-line": true,
+	"edit":         true,
+	"set":          true,
+}
+
+// editorHexProcessKey processes key in hex view. Keys, that are not
+// specific for hex view, must be processed by caller, see hexCommands.
+func (e *Editor) editorHexProcessKey(k Key) (processed bool) {
+	c := k.Code
+	h := &e.hex
+	size := len(h.data)
+	switch {
+	case c == ARROW_LEFT:
+		h.cursor--
+		h.nibble = 0
+	case c == ARROW_RIGHT:
+		h.cursor++
+		h.nibble = 0
+	case c == ARROW_UP:
+		h.cursor -= HEX_BYTES_PER_ROW
+	case c == ARROW_DOWN:
+		h.cursor += HEX_BYTES_PER_ROW
+	case c == PAGE_UP:
+		h.cursor -= HEX_BYTES_PER_ROW * e.screen.rows
+	case c == PAGE_DOWN:
+		h.cursor += HEX_BYTES_PER_ROW * e.screen.rows
+	case c == HOME_KEY:
+		h.cursor -= h.cursor % HEX_BYTES_PER_ROW
+		h.nibble = 0
+	case c == END_KEY:
+		h.cursor += HEX_BYTES_PER_ROW - 1 - h.cursor%HEX_BYTES_PER_ROW
+		h.nibble = 0
+	case c == '\t':
+		h.insert = !h.insert
+	case c == MOUSE_KEY:
+		// mouse is not supported in hex view
+	case c == PASTE_KEY:
+		// pasted hex digits
+		for _, c := range []byte(k.Text) {
+			if hexDigit(int(c)) >= 0 {
+				e.editorHexProcessKey(Key{Code: int(c)})
+			}
+		}
+	case c == BACKSPACE || c == ('h'&0x1f):
+		if h.cursor > 0 && h.cursor <= size {
+			h.data = append(h.data[:h.cursor-1], h.data[h.cursor:]...)
+			h.cursor--
+			h.nibble = 0
+			e.dirty = true
+		}
+	case c == DEL_KEY:
+		if h.cursor < size {
+			h.data = append(h.data[:h.cursor], h.data[h.cursor+1:]...)
+			h.nibble = 0
+			e.dirty = true
+		}
+	case hexDigit(c) >= 0:
+		d := byte(hexDigit(c))
+		if h.cursor == size || (h.insert && h.nibble == 0) {
+			h.data = append(h.data, 0)
+			copy(h.data[h.cursor+1:], h.data[h.cursor:])
+			h.data[h.cursor] = 0
+		}
+		if h.nibble == 0 {
+			h.data[h.cursor] = d<<4 | h.data[h.cursor]&0x0f
+			h.nibble = 1
+		} else {
+			h.data[h.cursor] = h.data[h.cursor]&0xf0 | d
+			h.nibble = 0
+			h.cursor++
+		}
+		e.dirty = true
+	default:
+		return false
+	}
+
+	// cursor may be placed after last byte for appending
+	if h.cursor > len(h.data) {
+		h.cursor = len(h.data)
+		h.nibble = 0
+	}
+	if h.cursor < 0 {
+		h.cursor = 0
+	}
+	return true
+}
+
+// hexDigit returns value of hex digit c or -1.
+func hexDigit(c int) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10
+	}
+	return -1
+}
+
+// editorHexScroll keeps the cursor row on the screen and returns the
+// cursor position on the screen.
+func (e *Editor) editorHexScroll() (y, x int) {
+	h := &e.hex
+	row := h.cursor / HEX_BYTES_PER_ROW
+	if row < h.offset {
+		h.offset = row
+	}
+	if row >= h.offset+e.screen.rows {
+		h.offset = row - e.screen.rows + 1
+	}
+	col := h.cursor % HEX_BYTES_PER_ROW
+	x = HEX_ADDRESS_WIDTH + 3*col + h.nibble
+	if col >= HEX_BYTES_PER_ROW/2 {
+		x++
+	}
+	return row - h.offset, x
+}
+
+func (e *Editor) editorDrawHex(ab *bytes.Buffer) {
+	h := &e.hex
+	for y := 0; y < e.screen.rows; y++ {
+		start := (y + h.offset) * HEX_BYTES_PER_ROW
+		if start > len(h.data) || (start == len(h.data) && start != 0 && start != h.cursor) {
+			ab.WriteString(e.highlight[HL_NONTEXT])
+			ab.WriteString("~")
+			ab.WriteString("\x1b[m")
+		} else {
+			var line bytes.Buffer
+			fmt.Fprintf(&line, "%08x  ", start)
+			var ascii bytes.Buffer
+			for i := 0; i < HEX_BYTES_PER_ROW; i++ {
+				if i == HEX_BYTES_PER_ROW/2 {
+					line.WriteByte(' ')
+				}
+				if start+i >= len(h.data) {
+					line.WriteString("   ")
+					continue
+				}
+				c := h.data[start+i]
+				fmt.Fprintf(&line, "%02x ", c)
+				if c < 0x20 || c >= 0x7f {
+					c = '.'
+				}
+				ascii.WriteByte(c)
+			}
+			fmt.Fprintf(&line, " |%s|", ascii.String())
+			b := line.Bytes()
+			if len(b) > e.screen.cols {
+				b = b[:e.screen.cols]
+			}
+			ab.WriteString(e.highlight[HL_NORMAL])
+			ab.Write(b)
+			ab.WriteString("\x1b[m")
+		}
+		ab.WriteString("\x1b[K")
+		ab.WriteString("\r\n")
+	}
+}
@@ -0,0 +1,132 @@
+package editor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHexRoundTrip(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "file.bin")
+	content := []byte("\x00\x01\r\n\xff\xfe text\r\r\n\x00")
+	if err := ioutil.WriteFile(filename, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Open(filename); err != nil {
+		t.Fatal(err)
+	}
+	if e.raw == nil {
+		t.Fatalf("binary file is not detected")
+	}
+	e.editorToggleHex()
+	if !bytes.Equal(e.hex.data, content) {
+		t.Fatalf("hex data is not same: %q", e.hex.data)
+	}
+
+	// overwrite first byte, insert byte after it, remove last byte
+	for _, c := range []int{'4', '1', '\t', 'f', 'F', END_KEY, ARROW_DOWN, BACKSPACE} {
+		if !e.editorHexProcessKey(Key{Code: c}) {
+			t.Fatalf("key %d is not processed", c)
+		}
+	}
+	expect := []byte("\x41\xff\x01\r\n\xff\xfe text\r\r\n")
+	if !bytes.Equal(e.hex.data, expect) {
+		t.Fatalf("unexpected hex data: %q", e.hex.data)
+	}
+
+	if err := e.Save(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, expect) {
+		t.Fatalf("saved file is not same: %q", b)
+	}
+
+	// text view keeps the raw content for saving
+	e.editorToggleHex()
+	if err := e.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ = ioutil.ReadFile(filename); !bytes.Equal(b, expect) {
+		t.Fatalf("saved file from text view is not same: %q", b)
+	}
+}
+
+func TestHexToText(t *testing.T) {
+	t.Parallel()
+	e := newTestEditor(t)
+	if err := e.Load(bytes.NewReader([]byte("\x00\x01ab\r\ncd"))); err != nil {
+		t.Fatal(err)
+	}
+	e.editorToggleHex()
+	for _, c := range []int{'f', 'f'} {
+		e.editorHexProcessKey(Key{Code: c})
+	}
+	e.editorToggleHex()
+	expect := "\xff\x01ab\r\ncd"
+	if out, err := e.Content(); err != nil || string(out) != expect || !e.dirty {
+		t.Fatalf("content %q %v of dirty %v, expected %q", out, err, e.dirty, expect)
+	}
+
+	// content of rows after text edit
+	e.cursor.y, e.cursor.x = 1, 2
+	e.InsertChar('e')
+	expect = "\xff\x01ab\ncde\n"
+	if out, err := e.Content(); err != nil || string(out) != expect {
+		t.Fatalf("content %q %v, expected %q", out, err, expect)
+	}
+}
+
+func TestHexIgnoredKeys(t *testing.T) {
+	t.Parallel()
+	e := newTestEditor(t)
+	if err := e.Load(bytes.NewReader([]byte("a\x00b\n"))); err != nil {
+		t.Fatal(err)
+	}
+	e.editorToggleHex()
+	e.term = &Mock{line: []Key{{Code: 'g'}, {Code: '\r'}, {Code: 'x' & 0x1f}, {Code: 'p' & 0x1f}, {Code: 'n'}, {Code: 'e'}, {Code: 'w'}, {Code: '\r'}}}
+	for i := 0; i < 4; i++ {
+		if _, err := e.ProcessKeypress(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if e.dirty || len(e.rows) != 1 || string(e.rows[0].chars) != "a\x00b" {
+		t.Errorf("text is changed in hex view: %v %d rows", e.dirty, len(e.rows))
+	}
+	if expect := "Command newline is not available in hex view"; e.status.msg != expect {
+		t.Errorf("got message %q, expected %q", e.status.msg, expect)
+	}
+}
+
+func TestBinaryMessage(t *testing.T) {
+	t.Parallel()
+	e := newTestEditor(t)
+	if err := e.Load(bytes.NewReader([]byte("a\x00b\n"))); err != nil {
+		t.Fatal(err)
+	}
+	var msg string
+	m := &Mock{line: []Key{{Code: 'q' & 0x1f}}, onRead: func(int) { msg = e.status.msg }}
+	if err := e.Run(m, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "Binary file detected. Press Ctrl-B for hex view."; msg != expect {
+		t.Errorf("got message %q, expected %q", msg, expect)
+	}
+}
@@ -0,0 +1,143 @@
+package editor
+
+import (
+	"bytes"
+	"io"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// input
+//
+// inputBuffer collects the bytes from terminal and assembles them into key
+// events. Escape sequence may be split between several reads on slow
+// links, so after the beginning of sequence the buffer waits the rest of
+// it up to escape timeout. Escape without continuation in the timeout is
+// a lone Escape key. Pasted text is waited longer and, if the end marker
+// of paste is lost, the received text is pasted.
+
+const (
+	KILO_ESCAPE_TIMEOUT = 50 * time.Millisecond
+	KILO_PASTE_TIMEOUT  = time.Second
+)
+
+type inputBuffer struct {
+	pending []byte        // read, but not decoded bytes
+	scanned int           // bytes of pending paste without end marker
+	timeout time.Duration // escape timeout
+	ti      *terminfo     // key sequences of terminal
+
+	// read returns the available bytes. It waits for the bytes not more
+	// than timeout and returns no bytes, when time is out. Negative
+	// timeout is waiting without limit.
+	read func(timeout time.Duration) ([]byte, error)
+}
+
+// readKey returns the next key event.
+func (in *inputBuffer) readKey() (Key, error) {
+	for {
+		paste := bytes.HasPrefix(in.pending, []byte(PASTE_START))
+		if paste {
+			if k, ok := in.pasteKey(); ok {
+				return k, nil
+			}
+		} else if k, n := in.ti.decodeKey(in.pending); n > 0 {
+			in.pending = in.pending[n:]
+			return k, nil
+		}
+		timeout := time.Duration(-1)
+		if paste {
+			timeout = KILO_PASTE_TIMEOUT
+		} else if len(in.pending) > 0 {
+			timeout = in.timeout
+		}
+		b, err := in.read(timeout)
+		if err != nil {
+			return Key{}, err
+		}
+		if len(b) > 0 {
+			in.pending = append(in.pending, b...)
+			continue
+		}
+		if len(in.pending) == 0 {
+			continue
+		}
+
+		// time is out for not finished escape sequence
+		var k Key
+		switch {
+		case paste:
+			k = Key{Code: PASTE_KEY, Text: string(in.pending[len(PASTE_START):])}
+		case len(in.pending) == 1:
+			k = Key{Code: '\x1b'}
+		case len(in.pending) == 2:
+			// "ESC [" and "ESC O"
+			k = Key{Code: int(in.pending[1]), Mod: MOD_ALT}
+		default:
+			k = Key{Code: UNKNOWN_KEY}
+		}
+		in.pending = nil
+		in.scanned = 0
+		return k, nil
+	}
+}
+
+// pasteKey returns the pasted text, if pending bytes contain the end of
+// paste. Only the bytes after the last search are searched, so large paste
+// of many reads is not searched again after each read.
+func (in *inputBuffer) pasteKey() (k Key, ok bool) {
+	start := len(PASTE_START)
+	if s := in.scanned - (len(PASTE_END) - 1); s > start {
+		start = s
+	}
+	end := bytes.Index(in.pending[start:], []byte(PASTE_END))
+	if end < 0 {
+		in.scanned = len(in.pending)
+		return k, false
+	}
+	end += start
+	k = Key{Code: PASTE_KEY, Text: string(in.pending[len(PASTE_START):end])}
+	in.pending = in.pending[end+len(PASTE_END):]
+	in.scanned = 0
+	return k, true
+}
+
+// readTimeout reads the available bytes from fd and waits for them not
+// more than timeout. Negative timeout is waiting without limit.
+func readTimeout(fd uintptr, timeout time.Duration) ([]byte, error) {
+	var fds syscall.FdSet
+	bits := int(unsafe.Sizeof(fds.Bits[0])) * 8
+	for {
+		fds = syscall.FdSet{}
+		fds.Bits[int(fd)/bits] |= 1 << (uint(fd) % uint(bits))
+		var tv *syscall.Timeval
+		if timeout >= 0 {
+			t := syscall.NsecToTimeval(timeout.Nanoseconds())
+			tv = &t
+		}
+		n, err := syscall.Select(int(fd)+1, &fds, nil, nil, tv)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, nil
+		}
+		var buffer [4096]byte
+		cc, err := syscall.Read(int(fd), buffer[:])
+		if err == syscall.EINTR || err == syscall.EAGAIN {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cc == 0 {
+			// terminal is readable, but without bytes
+			return nil, io.EOF
+		}
+		return buffer[:cc], nil
+	}
+}
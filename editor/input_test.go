@@ -0,0 +1,56 @@
+package editor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestInputBuffer(t *testing.T) {
+	tcs := []struct {
+		reads []string // empty string is a timeout
+		keys  []Key
+	}{
+		{[]string{"ab"}, []Key{{Code: 'a'}, {Code: 'b'}}},
+		{[]string{"\x1b", ""}, []Key{{Code: '\x1b'}}},
+		{[]string{"\x1b", "[A"}, []Key{{Code: ARROW_UP}}},
+		{[]string{"\x1b[", "1;5", "C"}, []Key{{Code: ARROW_RIGHT, Mod: MOD_CTRL}}},
+		{[]string{"\x1b[A\x1b", "[B"}, []Key{{Code: ARROW_UP}, {Code: ARROW_DOWN}}},
+		{[]string{"\x1b[", ""}, []Key{{Code: '[', Mod: MOD_ALT}}},
+		{[]string{"\x1b[1;", "", "a"}, []Key{{Code: UNKNOWN_KEY}, {Code: 'a'}}},
+		{[]string{"\x1b", "", "\x1b", "", "[", "A"}, []Key{{Code: '\x1b'}, {Code: '\x1b'}, {Code: '['}, {Code: 'A'}}},
+		{[]string{"", "", "q"}, []Key{{Code: 'q'}}},
+		{[]string{"\x1b[200~ab", "c\x1b[20", "1~"}, []Key{{Code: PASTE_KEY, Text: "abc"}}},
+		{[]string{"\x1b[200~ab", ""}, []Key{{Code: PASTE_KEY, Text: "ab"}}},
+		{[]string{"\x1b[200~a\x1b", "[201", "b", "\x1b[201~c"}, []Key{{Code: PASTE_KEY, Text: "a\x1b[201b"}, {Code: 'c'}}},
+		{[]string{"\x1b[200~a\x1b[2", "01~\x1b[200~b\x1b", "[201~"}, []Key{{Code: PASTE_KEY, Text: "a"}, {Code: PASTE_KEY, Text: "b"}}},
+	}
+	for i, tc := range tcs {
+		t.Run(fmt.Sprintf("%d:%q", i, tc.reads), func(t *testing.T) {
+			reads := tc.reads
+			in := inputBuffer{
+				timeout: time.Millisecond,
+				read: func(timeout time.Duration) ([]byte, error) {
+					if len(reads) == 0 {
+						t.Fatalf("not enough reads")
+					}
+					r := reads[0]
+					reads = reads[1:]
+					return []byte(r), nil
+				},
+			}
+			for _, expect := range tc.keys {
+				k, err := in.readKey()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if k != expect {
+					t.Fatalf("got %#v, expected %#v", k, expect)
+				}
+			}
+			if len(reads) != 0 || len(in.pending) != 0 {
+				t.Fatalf("not used input: %q %q", reads, in.pending)
+			}
+		})
+	}
+}
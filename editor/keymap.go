@@ -0,0 +1,216 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keymap
+//
+// Keymap binds keys to commands. Binding is a key or a chord of keys, like
+// "Ctrl-X Ctrl-S", names of keys are the same as in key script. Config file
+// binds keys by options:
+//
+//	key Ctrl-X Ctrl-S = save
+//	key Ctrl-Q = none
+//
+// Later binding replaces the binding of the same keys and bindings, that
+// are prefix of it or start with it. Keys, that are not bound, are typed
+// into buffer, if they are characters.
+
+// binding is the keys of chord and the name of command.
+type binding struct {
+	keys    string // names of keys separated by space
+	command string
+}
+
+// defaultBindings are bindings of keymap without config
+var defaultBindings = []binding{
+	{"Enter", "newline"},
+	{"Tab", "indent"},
+	{"Shift-Tab", "indent"},
+	{"Backspace", "delete-backward"},
+	{"Ctrl-H", "delete-backward"},
+	{"Delete", "delete-forward"},
+	{"ArrowLeft", "move-left"},
+	{"ArrowRight", "move-right"},
+	{"ArrowUp", "move-up"},
+	{"ArrowDown", "move-down"},
+	{"Ctrl-ArrowLeft", "move-word-left"},
+	{"Ctrl-ArrowRight", "move-word-right"},
+	{"Home", "line-start"},
+	{"End", "line-end"},
+	{"PageUp", "page-up"},
+	{"PageDown", "page-down"},
+	{"Ctrl-S", "save"},
+	{"Ctrl-E", "save-encoding"},
+	{"Ctrl-Q", "quit"},
+	{"Ctrl-B", "toggle-hex"},
+	{"Ctrl-T", "toggle-mouse"},
+	{"Ctrl-Z", "suspend"},
+	{"Ctrl-L", "redraw"},
+	{"Escape", "none"},
+	{"F1", "describe-key"},
+	{"Ctrl-P", "command-line"},
+}
+
+// keymap is bindings by keys and prefixes of chords.
+type keymap struct {
+	bindings map[string]string
+	prefixes map[string]bool
+}
+
+// newKeymap returns the keymap of bindings.
+func newKeymap(bindings []binding) *keymap {
+	m := &keymap{bindings: map[string]string{}, prefixes: map[string]bool{}}
+	for _, b := range bindings {
+		for keys := range m.bindings {
+			if strings.HasPrefix(keys+" ", b.keys+" ") || strings.HasPrefix(b.keys+" ", keys+" ") {
+				delete(m.bindings, keys)
+			}
+		}
+		m.bindings[b.keys] = b.command
+	}
+	for keys := range m.bindings {
+		fields := strings.Fields(keys)
+		for i := 1; i < len(fields); i++ {
+			m.prefixes[strings.Join(fields[:i], " ")] = true
+		}
+	}
+	return m
+}
+
+// keysOf returns the shortest keys bound to command or empty string, if
+// command is not bound.
+func (m *keymap) keysOf(command string) (keys string) {
+	for k, c := range m.bindings {
+		if c != command {
+			continue
+		}
+		if keys == "" || len(k) < len(keys) || len(k) == len(keys) && k < keys {
+			keys = k
+		}
+	}
+	return
+}
+
+// editorHelp returns the help line with keys of main commands.
+func (e *Editor) editorHelp() string {
+	help := "HELP:"
+	sep := " "
+	for _, c := range []struct{ command, help string }{
+		{"save", "save"},
+		{"command-line", "command line"},
+		{"describe-key", "describe key"},
+		{"quit", "quit"},
+	} {
+		if keys := e.keymap.keysOf(c.command); keys != "" {
+			help += sep + keys + " = " + c.help
+			sep = " | "
+		}
+	}
+	return help
+}
+
+// parseChord parses the names of keys separated by spaces and returns the
+// chord with names of keys as in keymap.
+func parseChord(s string) (string, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("no keys")
+	}
+	for i, f := range fields {
+		k, err := parseKeyName(f)
+		if err != nil {
+			return "", err
+		}
+		fields[i] = keyName(k)
+	}
+	return strings.Join(fields, " "), nil
+}
+
+// chordName returns the name of chord of keys.
+func chordName(keys []Key) string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = keyName(k)
+	}
+	return strings.Join(names, " ")
+}
+
+// editorKeyCommand returns the command of key k after keys of pending
+// chord and the keys of command. Prefix of chord is kept in e.chord until
+// the last key.
+func (e *Editor) editorKeyCommand(k Key) (cmd command, keys string, ok bool) {
+	e.chord = append(e.chord, k)
+	keys = chordName(e.chord)
+	if e.keymap.prefixes[keys] {
+		e.editorSetStatusMessage("%s-", keys)
+		return cmd, keys, false
+	}
+	chord := e.chord
+	e.chord = nil
+	if name, bound := e.keymap.bindings[keys]; bound {
+		return commands[name], keys, true
+	}
+	if len(chord) > 1 {
+		e.editorSetStatusMessage("%s is not bound", keys)
+		return commands["none"], keys, true
+	}
+	return e.editorUnboundKey(k), keys, true
+}
+
+// editorUnboundKey returns the command of key, that is not bound: typing
+// of character or nothing for other keys.
+func (e *Editor) editorUnboundKey(k Key) command {
+	if k.Mod&(MOD_ALT|MOD_CTRL|MOD_META) != 0 || k.Code >= 0x100 {
+		// not supported key
+		return commands["none"]
+	}
+	return command{
+		name: "self-insert",
+		help: "Type the character.",
+		run:  func(e *Editor, _ []string) error { e.InsertChar(byte(k.Code)); return nil },
+	}
+}
+
+// editorDescribeKey reads the key or chord and shows its command.
+func (e *Editor) editorDescribeKey() error {
+	var keys []Key
+	for {
+		e.editorSetStatusMessage("Describe key: %s", chordName(keys))
+		if err := e.Refresh(); err != nil {
+			return err
+		}
+		k, err := e.term.ReadKey()
+		if err != nil {
+			return err
+		}
+		if k.Code == RESIZE_KEY {
+			if err := e.editorUpdateWindowSize(); err != nil {
+				return err
+			}
+			e.editorInvalidateScreen()
+			continue
+		}
+		if k.Code == PASTE_KEY || k.Code == MOUSE_KEY {
+			e.editorSetStatusMessage("")
+			return nil
+		}
+		keys = append(keys, k)
+		name := chordName(keys)
+		if e.keymap.prefixes[name] {
+			continue
+		}
+		cmd, ok := commands[e.keymap.bindings[name]]
+		if !ok && len(keys) == 1 {
+			cmd = e.editorUnboundKey(k)
+		}
+		if cmd.name == "" || cmd.name == "none" {
+			e.editorSetStatusMessage("%s is not bound", name)
+		} else {
+			e.editorSetStatusMessage("%s runs %s: %s", name, cmd.name, cmd.help)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,134 @@
+package editor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeymap(t *testing.T) {
+	m := newKeymap([]binding{
+		{"Ctrl-X", "save"},
+		{"Ctrl-X Ctrl-S", "save"},
+		{"Ctrl-X Ctrl-C", "quit"},
+		{"Ctrl-K Ctrl-K", "redraw"},
+		{"Ctrl-K", "none"},
+	})
+	expect := map[string]string{"Ctrl-X Ctrl-S": "save", "Ctrl-X Ctrl-C": "quit", "Ctrl-K": "none"}
+	if !reflect.DeepEqual(m.bindings, expect) {
+		t.Errorf("unexpected bindings: %v", m.bindings)
+	}
+	if !reflect.DeepEqual(m.prefixes, map[string]bool{"Ctrl-X": true}) {
+		t.Errorf("unexpected prefixes: %v", m.prefixes)
+	}
+
+	for _, b := range defaultBindings {
+		if _, ok := commands[b.command]; !ok {
+			t.Errorf("%s: unknown command %q", b.keys, b.command)
+		}
+		if keys, err := parseChord(b.keys); err != nil || keys != b.keys {
+			t.Errorf("%s: not normalized keys %q %v", b.keys, keys, err)
+		}
+	}
+}
+
+func TestKeymapConfig(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		src, err string
+	}{
+		{"key Ctrl-X Ctrl-Foo = save", "x:1: key Ctrl-X Ctrl-Foo: unknown key \"Ctrl-Foo\""},
+		{"key F2 = sav", "x:1: key F2: unknown command \"sav\""},
+	}
+	for _, tc := range tcs {
+		_, err := parseConfig(strings.NewReader(tc.src), "x")
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("%q: got error %v, expected %q", tc.src, err, tc.err)
+		}
+	}
+
+	c, err := parseConfig(strings.NewReader("key ctrl-x ctrl-s = save\nkey Ctrl-S = none\nkey Alt-q = quit\n"), "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newTestEditor(t)
+	e.config = c
+	if err := e.editorLoadConfig(""); err != nil {
+		t.Fatal(err)
+	}
+	if help := e.editorHelp(); help != "HELP: Ctrl-X Ctrl-S = save | Ctrl-P = command line | F1 = describe key | Alt-q = quit" {
+		t.Errorf("unexpected help %q", help)
+	}
+
+	keys := func(names ...string) []Key {
+		var keys []Key
+		for _, name := range names {
+			k, err := parseKeyName(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys = append(keys, k)
+		}
+		return keys
+	}
+	m := &Mock{line: keys("a", "Ctrl-S", "Ctrl-X", "b", "Ctrl-X", "Ctrl-K", "c", "F1", "Ctrl-X", "Ctrl-S", "F1", "d", "Alt-q")}
+	e.term = m
+	press := func(msg string) {
+		t.Helper()
+		if _, err := e.ProcessKeypress(); err != nil {
+			t.Fatal(err)
+		}
+		if msg != "" && e.status.msg != msg {
+			t.Errorf("got message %q, expected %q", e.status.msg, msg)
+		}
+	}
+	press("")
+	press("")
+	if !e.dirty {
+		t.Fatalf("unbound Ctrl-S saves the buffer")
+	}
+	press("Ctrl-X-")
+	press("Ctrl-X b is not bound")
+	press("Ctrl-X-")
+	press("Ctrl-X Ctrl-K is not bound")
+	press("")
+	if got := string(e.rows[0].chars); got != "ac" {
+		t.Errorf("unexpected row %q", got)
+	}
+	press("Ctrl-X Ctrl-S runs save: Save the buffer to file.")
+	press("d runs self-insert: Type the character.")
+	if out, err := e.ProcessKeypress(); err != nil || out {
+		t.Fatalf("quit with changes: %v %v", out, err)
+	}
+	if !strings.Contains(e.status.msg, "Press Alt-q 3 more times") {
+		t.Errorf("unexpected warning %q", e.status.msg)
+	}
+}
+
+func TestMoveWord(t *testing.T) {
+	t.Parallel()
+	e := newTestEditor(t)
+	for i, row := range []string{"foo bar_1  baz", "", "  qux"} {
+		e.editorInsertRow(i, []byte(row))
+	}
+	tcs := []struct {
+		dir  int
+		y, x int
+	}{
+		{ARROW_RIGHT, 0, 4},
+		{ARROW_RIGHT, 0, 11},
+		{ARROW_RIGHT, 2, 2},
+		{ARROW_RIGHT, 2, 5},
+		{ARROW_LEFT, 2, 2},
+		{ARROW_LEFT, 0, 11},
+		{ARROW_LEFT, 0, 4},
+		{ARROW_LEFT, 0, 0},
+		{ARROW_LEFT, 0, 0},
+	}
+	for i, tc := range tcs {
+		e.editorMoveWord(tc.dir)
+		if e.cursor.y != tc.y || e.cursor.x != tc.x {
+			t.Fatalf("%d: cursor %d %d, expected %d %d", i, e.cursor.y, e.cursor.x, tc.y, tc.x)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package editor
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// keys
+//
+// decodeKey converts the raw bytes from terminal into key event. It
+// supports:
+//
+//	ESC [ <params> <final>       CSI sequences: arrows, Home/End, F1-F12,
+//	                             Insert/Delete, PageUp/PageDown with
+//	                             modifiers, like "\x1b[1;5C" (Ctrl-Right)
+//	ESC O <final>                SS3 sequences: arrows, F1-F4
+//	ESC [ 27 ; <mod> ; <code> ~  xterm modifyOtherKeys
+//	ESC [ <code> ; <mod> u       kitty keyboard protocol
+//	ESC <char>                   Alt-<char>
+//	ESC [ 200 ~ <text> ESC [ 201 ~  bracketed paste
+//	ESC [ < <b> ; <x> ; <y> M/m  SGR (1006) mouse event
+//
+// Key sequences of terminfo entry are checked first. Console.start asks
+// terminal for modifyOtherKeys and kitty keyboard protocol, terminals
+// without them send the legacy sequences.
+
+// Modifier is a bitmask of key modifiers.
+type Modifier int
+
+const (
+	MOD_SHIFT Modifier = 1 << iota
+	MOD_ALT
+	MOD_CTRL
+	MOD_META
+)
+
+// Key is a key event: character or special key code with modifiers.
+// Pasted text is a single event PASTE_KEY with the text, mouse event is
+// MOUSE_KEY.
+type Key struct {
+	Code  int
+	Mod   Modifier
+	Text  string
+	Mouse Mouse
+}
+
+// Mouse is a mouse event. Coordinates are zero-based screen position.
+type Mouse struct {
+	Button int
+	Action int
+	X, Y   int
+}
+
+const (
+	MOUSE_LEFT = iota
+	MOUSE_MIDDLE
+	MOUSE_RIGHT
+	MOUSE_WHEEL_UP
+	MOUSE_WHEEL_DOWN
+)
+
+const (
+	MOUSE_PRESS = iota
+	MOUSE_RELEASE
+	MOUSE_DRAG
+)
+
+// special keys after PAGE_DOWN
+const (
+	INSERT_KEY = PAGE_DOWN + 1 + iota
+	F1_KEY
+	F2_KEY
+	F3_KEY
+	F4_KEY
+	F5_KEY
+	F6_KEY
+	F7_KEY
+	F8_KEY
+	F9_KEY
+	F10_KEY
+	F11_KEY
+	F12_KEY
+	UNKNOWN_KEY // not supported escape sequence
+	PASTE_KEY   // bracketed paste
+	MOUSE_KEY   // mouse event
+	RESIZE_KEY  // window size is changed
+)
+
+// bracketed paste markers
+const (
+	PASTE_START = "\x1b[200~"
+	PASTE_END   = "\x1b[201~"
+)
+
+// csiTildeKeys are keys of sequences "ESC [ <number> ~"
+var csiTildeKeys = map[int]int{
+	1:  HOME_KEY,
+	2:  INSERT_KEY,
+	3:  DEL_KEY,
+	4:  END_KEY,
+	5:  PAGE_UP,
+	6:  PAGE_DOWN,
+	7:  HOME_KEY,
+	8:  END_KEY,
+	11: F1_KEY,
+	12: F2_KEY,
+	13: F3_KEY,
+	14: F4_KEY,
+	15: F5_KEY,
+	17: F6_KEY,
+	18: F7_KEY,
+	19: F8_KEY,
+	20: F9_KEY,
+	21: F10_KEY,
+	23: F11_KEY,
+	24: F12_KEY,
+}
+
+// letterKeys are keys of sequences "ESC [ <letter>" and "ESC O <letter>"
+var letterKeys = map[byte]int{
+	'A': ARROW_UP,
+	'B': ARROW_DOWN,
+	'C': ARROW_RIGHT,
+	'D': ARROW_LEFT,
+	'H': HOME_KEY,
+	'F': END_KEY,
+	'P': F1_KEY,
+	'Q': F2_KEY,
+	'R': F3_KEY,
+	'S': F4_KEY,
+}
+
+// decodeKey decodes the first key event in b and returns the amount of
+// used bytes. Zero n means, that b is empty or contains only the
+// beginning of escape sequence and more bytes are needed. Key sequences
+// of terminfo entry ti are decoded first.
+func (ti *terminfo) decodeKey(b []byte) (k Key, n int) {
+	if len(b) == 0 {
+		return Key{}, 0
+	}
+	if b[0] != '\x1b' {
+		return Key{Code: int(b[0])}, 1
+	}
+	if len(b) == 1 {
+		return Key{}, 0
+	}
+	if k, n, ok := ti.decodeTerminfoKey(b); ok {
+		return k, n
+	}
+	switch b[1] {
+	case '[':
+		if bytes.HasPrefix(b, []byte(PASTE_START)) {
+			end := bytes.Index(b, []byte(PASTE_END))
+			if end < 0 {
+				return Key{}, 0
+			}
+			return Key{Code: PASTE_KEY, Text: string(b[len(PASTE_START):end])}, end + len(PASTE_END)
+		}
+		if len(b) > 2 && b[2] == '<' {
+			return decodeMouse(b)
+		}
+		return decodeCSI(b)
+	case 'O':
+		if len(b) < 3 {
+			return Key{}, 0
+		}
+		// SS3 with optional modifier, like "ESC O 5 C"
+		i := 2
+		for i < len(b) && '0' <= b[i] && b[i] <= '9' {
+			i++
+		}
+		if i == len(b) {
+			return Key{}, 0
+		}
+		code, ok := letterKeys[b[i]]
+		if !ok {
+			return Key{Code: UNKNOWN_KEY}, i + 1
+		}
+		mod := 1
+		if i > 2 {
+			mod, _ = strconv.Atoi(string(b[2:i]))
+		}
+		return normalizeKey(Key{Code: code, Mod: modifier(mod)}), i + 1
+	case '\x1b':
+		// double escape is a lone escape and start of next sequence
+		return Key{Code: '\x1b'}, 1
+	}
+	// Alt-<char>
+	return normalizeKey(Key{Code: int(b[1]), Mod: MOD_ALT}), 2
+}
+
+// decodeTerminfoKey decodes the longest key sequence of terminfo entry
+// at the beginning of b. Zero n with ok is the beginning of key sequence.
+func (ti *terminfo) decodeTerminfoKey(b []byte) (k Key, n int, ok bool) {
+	if ti == nil {
+		return
+	}
+	for seq, code := range ti.keys {
+		switch {
+		case len(seq) <= len(b) && string(b[:len(seq)]) == seq:
+			if len(seq) > n {
+				k, n, ok = Key{Code: code}, len(seq), true
+			}
+		case n == 0 && len(seq) > len(b) && seq[:len(b)] == string(b):
+			ok = true
+		}
+	}
+	return
+}
+
+// decodeCSI decodes sequence "ESC [ <params> <final>".
+func decodeCSI(b []byte) (k Key, n int) {
+	i := 2
+	for i < len(b) && 0x30 <= b[i] && b[i] <= 0x3f { // parameter bytes
+		i++
+	}
+	for i < len(b) && 0x20 <= b[i] && b[i] <= 0x2f { // intermediate bytes
+		i++
+	}
+	if i == len(b) {
+		return Key{}, 0
+	}
+	final := b[i]
+	n = i + 1
+	if final < 0x40 || 0x7e < final {
+		// broken sequence
+		return Key{Code: UNKNOWN_KEY}, n
+	}
+
+	// parameters "1;5" or with sub-parameters "97:65;2"
+	var params []int
+	if i > 2 {
+		for _, p := range bytes.Split(b[2:i], []byte(";")) {
+			if j := bytes.IndexByte(p, ':'); j >= 0 {
+				p = p[:j]
+			}
+			v, err := strconv.Atoi(string(p))
+			if err != nil {
+				v = 0
+			}
+			params = append(params, v)
+		}
+	}
+	param := func(index, def int) int {
+		if index < len(params) && params[index] != 0 {
+			return params[index]
+		}
+		return def
+	}
+
+	switch final {
+	case '~':
+		if param(0, 0) == 27 {
+			// modifyOtherKeys "ESC [ 27 ; <mod> ; <code> ~"
+			return normalizeKey(Key{Code: param(2, 0), Mod: modifier(param(1, 1))}), n
+		}
+		code, ok := csiTildeKeys[param(0, 0)]
+		if !ok {
+			return Key{Code: UNKNOWN_KEY}, n
+		}
+		return normalizeKey(Key{Code: code, Mod: modifier(param(1, 1))}), n
+	case 'u':
+		// kitty "ESC [ <code> ; <mod> u"
+		return normalizeKey(Key{Code: param(0, 0), Mod: modifier(param(1, 1))}), n
+	case 'Z':
+		return Key{Code: '\t', Mod: MOD_SHIFT}, n
+	}
+	if code, ok := letterKeys[final]; ok {
+		return normalizeKey(Key{Code: code, Mod: modifier(param(1, 1))}), n
+	}
+	return Key{Code: UNKNOWN_KEY}, n
+}
+
+// decodeMouse decodes SGR mouse sequence "ESC [ < <b> ; <x> ; <y> M/m".
+// Final "M" is press and "m" is release.
+func decodeMouse(b []byte) (k Key, n int) {
+	i := 3
+	for i < len(b) && ('0' <= b[i] && b[i] <= '9' || b[i] == ';') {
+		i++
+	}
+	if i == len(b) {
+		return Key{}, 0
+	}
+	n = i + 1
+	var params [3]int
+	ps := bytes.Split(b[3:i], []byte(";"))
+	if len(ps) != 3 || (b[i] != 'M' && b[i] != 'm') {
+		return Key{Code: UNKNOWN_KEY}, n
+	}
+	for j, p := range ps {
+		params[j], _ = strconv.Atoi(string(p))
+	}
+
+	k.Code = MOUSE_KEY
+	cb := params[0]
+	if cb&4 != 0 {
+		k.Mod |= MOD_SHIFT
+	}
+	if cb&8 != 0 {
+		k.Mod |= MOD_ALT
+	}
+	if cb&16 != 0 {
+		k.Mod |= MOD_CTRL
+	}
+	k.Mouse.X, k.Mouse.Y = params[1]-1, params[2]-1
+	switch {
+	case cb&64 != 0:
+		k.Mouse.Button = MOUSE_WHEEL_UP + cb&1
+	default:
+		k.Mouse.Button = MOUSE_LEFT + cb&3
+	}
+	switch {
+	case b[i] == 'm':
+		k.Mouse.Action = MOUSE_RELEASE
+	case cb&32 != 0:
+		k.Mouse.Action = MOUSE_DRAG
+	}
+	return k, n
+}
+
+// modifier converts modifier parameter of escape sequence into Modifier.
+func modifier(param int) Modifier {
+	if param < 1 {
+		return 0
+	}
+	return Modifier(param - 1)
+}
+
+// normalizeKey converts key event into the form of simple terminal input:
+// Ctrl-<letter> is control character, Shift-<char> is shifted character.
+func normalizeKey(k Key) Key {
+	if k.Code >= 0x80 || k.Code <= 0 {
+		return k
+	}
+	if k.Mod&MOD_CTRL != 0 {
+		switch {
+		case 'a' <= k.Code && k.Code <= 'z', 'A' <= k.Code && k.Code <= 'Z':
+			k.Code &= 0x1f
+			k.Mod &^= MOD_CTRL | MOD_SHIFT
+		}
+	}
+	if k.Mod&MOD_SHIFT != 0 && 0x20 < k.Code && k.Code < 0x7f {
+		if 'a' <= k.Code && k.Code <= 'z' {
+			k.Code -= 'a' - 'A'
+		}
+		k.Mod &^= MOD_SHIFT
+	}
+	return k
+}
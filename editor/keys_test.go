@@ -0,0 +1,129 @@
+package editor
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDecodeKey(t *testing.T) {
+	tcs := []struct {
+		in  string
+		key Key
+		n   int
+	}{
+		// simple bytes
+		{"a", Key{Code: 'a'}, 1},
+		{"ab", Key{Code: 'a'}, 1},
+		{"\r", Key{Code: '\r'}, 1},
+		{"\x7f", Key{Code: BACKSPACE}, 1},
+		{"\x11", Key{Code: 'q' & 0x1f}, 1},
+		{"\xd0\x9f", Key{Code: 0xd0}, 1},
+
+		// incomplete sequences
+		{"", Key{}, 0},
+		{"\x1b", Key{}, 0},
+		{"\x1b[", Key{}, 0},
+		{"\x1b[1;5", Key{}, 0},
+		{"\x1bO", Key{}, 0},
+		{"\x1b[27;5;11", Key{}, 0},
+
+		// CSI
+		{"\x1b[A", Key{Code: ARROW_UP}, 3},
+		{"\x1b[B", Key{Code: ARROW_DOWN}, 3},
+		{"\x1b[C", Key{Code: ARROW_RIGHT}, 3},
+		{"\x1b[D", Key{Code: ARROW_LEFT}, 3},
+		{"\x1b[H", Key{Code: HOME_KEY}, 3},
+		{"\x1b[F", Key{Code: END_KEY}, 3},
+		{"\x1b[Ca", Key{Code: ARROW_RIGHT}, 3},
+		{"\x1b[1;5C", Key{Code: ARROW_RIGHT, Mod: MOD_CTRL}, 6},
+		{"\x1b[1;2D", Key{Code: ARROW_LEFT, Mod: MOD_SHIFT}, 6},
+		{"\x1b[1;3A", Key{Code: ARROW_UP, Mod: MOD_ALT}, 6},
+		{"\x1b[1;6B", Key{Code: ARROW_DOWN, Mod: MOD_SHIFT | MOD_CTRL}, 6},
+		{"\x1b[1;8H", Key{Code: HOME_KEY, Mod: MOD_SHIFT | MOD_ALT | MOD_CTRL}, 6},
+		{"\x1b[1~", Key{Code: HOME_KEY}, 4},
+		{"\x1b[2~", Key{Code: INSERT_KEY}, 4},
+		{"\x1b[3~", Key{Code: DEL_KEY}, 4},
+		{"\x1b[3;5~", Key{Code: DEL_KEY, Mod: MOD_CTRL}, 6},
+		{"\x1b[4~", Key{Code: END_KEY}, 4},
+		{"\x1b[5~", Key{Code: PAGE_UP}, 4},
+		{"\x1b[6~", Key{Code: PAGE_DOWN}, 4},
+		{"\x1b[7~", Key{Code: HOME_KEY}, 4},
+		{"\x1b[8~", Key{Code: END_KEY}, 4},
+		{"\x1b[11~", Key{Code: F1_KEY}, 5},
+		{"\x1b[12~", Key{Code: F2_KEY}, 5},
+		{"\x1b[13~", Key{Code: F3_KEY}, 5},
+		{"\x1b[14~", Key{Code: F4_KEY}, 5},
+		{"\x1b[15~", Key{Code: F5_KEY}, 5},
+		{"\x1b[17~", Key{Code: F6_KEY}, 5},
+		{"\x1b[18~", Key{Code: F7_KEY}, 5},
+		{"\x1b[19~", Key{Code: F8_KEY}, 5},
+		{"\x1b[20~", Key{Code: F9_KEY}, 5},
+		{"\x1b[21~", Key{Code: F10_KEY}, 5},
+		{"\x1b[23~", Key{Code: F11_KEY}, 5},
+		{"\x1b[24~", Key{Code: F12_KEY}, 5},
+		{"\x1b[24;2~", Key{Code: F12_KEY, Mod: MOD_SHIFT}, 7},
+		{"\x1b[1;5P", Key{Code: F1_KEY, Mod: MOD_CTRL}, 6},
+		{"\x1b[Z", Key{Code: '\t', Mod: MOD_SHIFT}, 3},
+		{"\x1b[99~", Key{Code: UNKNOWN_KEY}, 5},
+		{"\x1b[?1;2c", Key{Code: UNKNOWN_KEY}, 7},
+
+		// SS3
+		{"\x1bOA", Key{Code: ARROW_UP}, 3},
+		{"\x1bOB", Key{Code: ARROW_DOWN}, 3},
+		{"\x1bOC", Key{Code: ARROW_RIGHT}, 3},
+		{"\x1bOD", Key{Code: ARROW_LEFT}, 3},
+		{"\x1bOH", Key{Code: HOME_KEY}, 3},
+		{"\x1bOF", Key{Code: END_KEY}, 3},
+		{"\x1bOP", Key{Code: F1_KEY}, 3},
+		{"\x1bOQ", Key{Code: F2_KEY}, 3},
+		{"\x1bOR", Key{Code: F3_KEY}, 3},
+		{"\x1bOS", Key{Code: F4_KEY}, 3},
+		{"\x1bO5C", Key{Code: ARROW_RIGHT, Mod: MOD_CTRL}, 4},
+
+		// Alt
+		{"\x1ba", Key{Code: 'a', Mod: MOD_ALT}, 2},
+		{"\x1bx[", Key{Code: 'x', Mod: MOD_ALT}, 2},
+		{"\x1b\r", Key{Code: '\r', Mod: MOD_ALT}, 2},
+		{"\x1b\x1b[A", Key{Code: '\x1b'}, 1},
+
+		// xterm modifyOtherKeys
+		{"\x1b[27;5;115~", Key{Code: 's' & 0x1f}, 11},
+		{"\x1b[27;2;65~", Key{Code: 'A'}, 10},
+		{"\x1b[27;3;120~", Key{Code: 'x', Mod: MOD_ALT}, 11},
+		{"\x1b[27;5;13~", Key{Code: '\r', Mod: MOD_CTRL}, 10},
+
+		// kitty
+		{"\x1b[97u", Key{Code: 'a'}, 5},
+		{"\x1b[97;5u", Key{Code: 'a' & 0x1f}, 7},
+		{"\x1b[97;2u", Key{Code: 'A'}, 7},
+		{"\x1b[97:65;2u", Key{Code: 'A'}, 10},
+		{"\x1b[113;7u", Key{Code: 'q' & 0x1f, Mod: MOD_ALT}, 8},
+		{"\x1b[13;5u", Key{Code: '\r', Mod: MOD_CTRL}, 7},
+		{"\x1b[27u", Key{Code: '\x1b'}, 5},
+		{"\x1b[127;3u", Key{Code: BACKSPACE, Mod: MOD_ALT}, 8},
+
+		// bracketed paste
+		{"\x1b[200~ab\r\ncd\x1b[201~x", Key{Code: PASTE_KEY, Text: "ab\r\ncd"}, 18},
+		{"\x1b[200~\x1b[A\x1b[201~", Key{Code: PASTE_KEY, Text: "\x1b[A"}, 15},
+		{"\x1b[200~ab", Key{}, 0},
+
+		// SGR mouse
+		{"\x1b[<0;10;5M", Key{Code: MOUSE_KEY, Mouse: Mouse{Button: MOUSE_LEFT, X: 9, Y: 4}}, 10},
+		{"\x1b[<0;10;5m", Key{Code: MOUSE_KEY, Mouse: Mouse{Button: MOUSE_LEFT, Action: MOUSE_RELEASE, X: 9, Y: 4}}, 10},
+		{"\x1b[<32;1;2M", Key{Code: MOUSE_KEY, Mouse: Mouse{Button: MOUSE_LEFT, Action: MOUSE_DRAG, X: 0, Y: 1}}, 10},
+		{"\x1b[<2;3;4M", Key{Code: MOUSE_KEY, Mouse: Mouse{Button: MOUSE_RIGHT, X: 2, Y: 3}}, 9},
+		{"\x1b[<64;3;4M", Key{Code: MOUSE_KEY, Mouse: Mouse{Button: MOUSE_WHEEL_UP, X: 2, Y: 3}}, 10},
+		{"\x1b[<65;3;4M", Key{Code: MOUSE_KEY, Mouse: Mouse{Button: MOUSE_WHEEL_DOWN, X: 2, Y: 3}}, 10},
+		{"\x1b[<16;3;4M", Key{Code: MOUSE_KEY, Mod: MOD_CTRL, Mouse: Mouse{Button: MOUSE_LEFT, X: 2, Y: 3}}, 10},
+		{"\x1b[<0;10", Key{}, 0},
+	}
+	for i, tc := range tcs {
+		t.Run(fmt.Sprintf("%d:%q", i, tc.in), func(t *testing.T) {
+			key, n := xterm.decodeKey([]byte(tc.in))
+			if key != tc.key || n != tc.n {
+				t.Fatalf("got %#v with %d bytes, expected %#v with %d bytes",
+					key, n, tc.key, tc.n)
+			}
+		})
+	}
+}
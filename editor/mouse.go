@@ -0,0 +1,124 @@
+package editor
+
+import (
+	"io"
+)
+
+// mouse
+//
+// Mouse reporting is xterm button-event tracking (1002) with SGR (1006)
+// coordinates. Click places the cursor, drag selects the text from the
+// click position, wheel scrolls the buffer. Clicks on status and message
+// bar are ignored: editor has only one buffer, so there is no buffer to
+// switch to.
+
+const KILO_WHEEL_ROWS = 3
+
+func (c *Console) EnableMouse(enable bool) error {
+	seq := "\x1b[?1000l\x1b[?1002l\x1b[?1006l"
+	if enable {
+		seq = "\x1b[?1000h\x1b[?1002h\x1b[?1006h"
+	}
+	_, err := io.WriteString(c.out, seq)
+	return err
+}
+
+// editorToggleMouse enables or disables mouse reporting.
+func (e *Editor) editorToggleMouse() {
+	if err := e.term.EnableMouse(!e.mouse); err != nil {
+		e.editorSetStatusMessage("Cannot switch mouse: %v", err)
+		return
+	}
+	e.mouse = !e.mouse
+	if e.mouse {
+		e.editorSetStatusMessage("Mouse enabled")
+	} else {
+		e.editorSetStatusMessage("Mouse disabled")
+	}
+}
+
+// editorMouse processes the mouse event.
+func (e *Editor) editorMouse(m Mouse) {
+	if m.Y >= e.screen.rows {
+		// status and message bar. There is only one buffer, so
+		// nothing to switch.
+		return
+	}
+	switch m.Button {
+	case MOUSE_WHEEL_UP:
+		e.offset.row -= KILO_WHEEL_ROWS
+		if e.offset.row < 0 {
+			e.offset.row = 0
+		}
+		if bottom := e.offset.row + e.screen.rows - 1; e.cursor.y > bottom {
+			e.cursor.y = bottom
+		}
+		e.editorMoveCursor(0) // fix cursor column
+		return
+	case MOUSE_WHEEL_DOWN:
+		e.offset.row += KILO_WHEEL_ROWS
+		if e.offset.row > len(e.rows) {
+			e.offset.row = len(e.rows)
+		}
+		if e.cursor.y < e.offset.row {
+			e.cursor.y = e.offset.row
+		}
+		e.editorMoveCursor(0) // fix cursor column
+		return
+	case MOUSE_LEFT:
+	default:
+		return
+	}
+
+	switch m.Action {
+	case MOUSE_PRESS:
+		e.editorMouseCursor(m)
+		e.selection.active = false
+		e.selection.anchor.x, e.selection.anchor.y = e.cursor.x, e.cursor.y
+	case MOUSE_DRAG:
+		e.editorMouseCursor(m)
+		e.selection.active = e.cursor != e.selection.anchor
+	}
+}
+
+// editorMouseCursor places the cursor at the screen position of mouse.
+func (e *Editor) editorMouseCursor(m Mouse) {
+	e.cursor.y = e.offset.row + m.Y
+	if e.cursor.y >= len(e.rows) {
+		e.cursor.y = len(e.rows)
+		e.cursor.x = 0
+		return
+	}
+	if e.cursor.y < 0 {
+		e.cursor.y = 0
+	}
+	rx := e.offset.col + m.X
+	if rx < 0 {
+		rx = 0
+	}
+	e.cursor.x = e.editorRowRxToCx(&e.rows[e.cursor.y], rx)
+}
+
+// editorSelectionRx returns the selected render columns [start, end) of
+// row at. End -1 is selection up to end of row.
+func (e *Editor) editorSelectionRx(at int) (start, end int, ok bool) {
+	if !e.selection.active {
+		return
+	}
+	from, to := e.selection.anchor, e.cursor
+	if from.y > to.y || (from.y == to.y && from.x > to.x) {
+		from, to = to, from
+	}
+	if at < from.y || to.y < at || len(e.rows) <= at {
+		return
+	}
+	row := &e.rows[at]
+	start, end = 0, -1
+	if at == from.y {
+		start = e.editorRowCxToRx(row, from.x)
+	}
+	if at == to.y {
+		end = e.editorRowCxToRx(row, to.x)
+	}
+	return start, end, true
+}
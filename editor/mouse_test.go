@@ -0,0 +1,51 @@
+package editor
+
+import (
+	"testing"
+)
+
+func TestMouse(t *testing.T) {
+	t.Parallel()
+	e := newTestEditor(t)
+	e.screen.rows, e.screen.cols = 3, 20
+	for _, line := range []string{"zero", "\tone", "two", "three", "four", "five"} {
+		e.editorInsertRow(len(e.rows), []byte(line))
+	}
+	e.offset.row = 1
+
+	mouse := func(button, action, x, y int) Key {
+		return Key{Code: MOUSE_KEY, Mouse: Mouse{Button: button, Action: action, X: x, Y: y}}
+	}
+	m := &Mock{line: []Key{
+		mouse(MOUSE_LEFT, MOUSE_PRESS, 5, 0), // after the tab
+		mouse(MOUSE_LEFT, MOUSE_DRAG, 2, 1),
+		mouse(MOUSE_LEFT, MOUSE_RELEASE, 2, 1),
+		mouse(MOUSE_WHEEL_DOWN, MOUSE_PRESS, 0, 0),
+		mouse(MOUSE_LEFT, MOUSE_PRESS, 0, 3), // status bar
+		{Code: 't' & 0x1f},
+	}}
+	e.term = m
+	m.mouse, e.mouse = true, true
+
+	check := func(y, x int, selected bool) {
+		t.Helper()
+		if _, err := e.ProcessKeypress(); err != nil {
+			t.Fatal(err)
+		}
+		if e.cursor.y != y || e.cursor.x != x || e.selection.active != selected {
+			t.Fatalf("unexpected cursor %d:%d and selection %v", e.cursor.y, e.cursor.x, e.selection.active)
+		}
+	}
+	check(1, 2, false)
+	check(2, 2, true)
+	if start, end, ok := e.editorSelectionRx(1); !ok || start != 5 || end != -1 {
+		t.Fatalf("unexpected selection of row 1: %d %d %v", start, end, ok)
+	}
+	check(2, 2, true)
+	check(4, 2, true)
+	check(4, 2, true)
+	check(4, 2, false)
+	if m.mouse || e.mouse {
+		t.Fatalf("mouse is not disabled")
+	}
+}
@@ -0,0 +1,212 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// screen
+//
+// The screen keeps the last drawn frame. The new frame is compared with it
+// cell by cell and only the rest of changed lines from the first changed
+// cell is written to the terminal.
+//
+// The drawn frame uses ANSI sequences SGR "ESC [ ... m" and EL "ESC [ K".
+// The output is written by capabilities of terminfo entry.
+
+// screenCell is one character on the screen with the SGR sequences, that
+// are active for it.
+type screenCell struct {
+	attr string
+	ch   string
+}
+
+type screenFrame struct {
+	lines  [][]screenCell
+	cols   int // width of screen
+	cursor struct{ y, x int }
+}
+
+type screenState struct {
+	last  screenFrame
+	valid bool // last frame is on the terminal
+}
+
+// editorInvalidateScreen forces full redraw on next refresh.
+func (e *Editor) editorInvalidateScreen() {
+	e.drawn.valid = false
+}
+
+// parseScreenLine splits the drawn line into cells. The line may contain
+// only SGR escape sequences.
+func parseScreenLine(line []byte) (cells []screenCell) {
+	attr := ""
+	for len(line) > 0 {
+		if line[0] == '\x1b' && len(line) > 1 && line[1] == '[' {
+			end := bytes.IndexByte(line, 'm')
+			if end < 0 {
+				break
+			}
+			seq := string(line[:end+1])
+			line = line[end+1:]
+			if seq == "\x1b[m" || seq == "\x1b[0m" {
+				attr = ""
+			} else if strings.HasPrefix(seq, "\x1b[0;") {
+				attr = "\x1b[" + seq[4:]
+			} else {
+				attr += seq
+			}
+			continue
+		}
+		_, size := utf8.DecodeRune(line)
+		cells = append(cells, screenCell{attr: attr, ch: string(line[:size])})
+		line = line[size:]
+	}
+	return
+}
+
+// splitScreenLines splits the drawn screen into lines. Each line ends by
+// "\x1b[K\r\n", except the last one.
+func splitScreenLines(ab []byte) (lines [][]screenCell) {
+	for _, line := range bytes.Split(ab, []byte("\r\n")) {
+		line = bytes.Replace(line, []byte("\x1b[K"), nil, -1)
+		lines = append(lines, parseScreenLine(line))
+	}
+	return
+}
+
+// render writes into out the difference between the last frame and frame.
+func (e *Editor) render(f screenFrame, out *bytes.Buffer) {
+	full := !e.drawn.valid || len(e.drawn.last.lines) != len(f.lines) || e.drawn.last.cols != f.cols
+	if full {
+		out.WriteString(e.ti.str("civis"))
+		out.WriteString(e.ti.str("clear"))
+	}
+
+	attr := ""                        // active attributes on the terminal
+	cur := struct{ y, x int }{-2, -2} // cursor position on the terminal, if known
+	if full {
+		cur.y, cur.x = 0, 0
+	}
+	hidden := full
+	for y, line := range f.lines {
+		first := 0
+		var last []screenCell
+		if !full {
+			last = e.drawn.last.lines[y]
+			for first < len(line) && first < len(last) && line[first] == last[first] {
+				first++
+			}
+			if first == len(line) && first == len(last) {
+				continue
+			}
+		}
+		if !hidden {
+			out.WriteString(e.ti.str("civis"))
+			hidden = true
+		}
+
+		// move cursor
+		switch {
+		case cur.y == y && cur.x == first:
+		case cur.y == y-1 && first == 0:
+			out.WriteString("\r\n")
+		default:
+			out.WriteString(e.ti.str("cup", y, first))
+		}
+
+		for _, c := range line[first:] {
+			if c.attr != attr {
+				if attr != "" {
+					out.WriteString(e.ti.str("sgr0"))
+				}
+				out.WriteString(e.ti.attr(c.attr))
+				attr = c.attr
+			}
+			out.WriteString(c.ch)
+		}
+		if attr != "" {
+			out.WriteString(e.ti.str("sgr0"))
+			attr = ""
+		}
+		if len(line) < len(last) && len(line) < f.cols {
+			// clear rest of line, but not the last character in pending
+			// wrap state of full width line
+			out.WriteString(e.ti.str("el"))
+		}
+		cur.y, cur.x = y, len(line)
+	}
+
+	if hidden || f.cursor != e.drawn.last.cursor {
+		out.WriteString(e.ti.str("cup", f.cursor.y, f.cursor.x))
+	}
+	if hidden {
+		out.WriteString(e.ti.str("cnorm"))
+	}
+	e.drawn.last = f
+	e.drawn.valid = true
+}
+
+// attr converts SGR sequences of cell attributes into sequences of
+// terminfo entry. Parameters without capability are written as is, colors
+// of theme are already degraded to the colors of terminal.
+func (ti *terminfo) attr(attr string) string {
+	var out strings.Builder
+	for _, seq := range strings.SplitAfter(attr, "m") {
+		if !strings.HasPrefix(seq, "\x1b[") {
+			continue
+		}
+		params := strings.Split(seq[2:len(seq)-1], ";")
+		for i := 0; i < len(params); i++ {
+			v, err := strconv.Atoi(params[i])
+			if err != nil {
+				continue
+			}
+			var s string
+			switch {
+			case v == 1:
+				s = ti.str("bold")
+			case v == 3:
+				s = ti.str("sitm")
+			case v == 4:
+				s = ti.str("smul")
+			case v == 7:
+				s = ti.str("rev")
+			case 30 <= v && v <= 37:
+				s = ti.str("setaf", v-30)
+			case 40 <= v && v <= 47:
+				s = ti.str("setab", v-40)
+			case 90 <= v && v <= 97 && ti.numbers["colors"] >= 16:
+				s = ti.str("setaf", v-90+8)
+			case 100 <= v && v <= 107 && ti.numbers["colors"] >= 16:
+				s = ti.str("setab", v-100+8)
+			case (v == 38 || v == 48) && i+2 < len(params) && params[i+1] == "5":
+				// 256-color "38;5;<n>"
+				n, _ := strconv.Atoi(params[i+2])
+				if ti.numbers["colors"] >= 256 {
+					name := "setaf"
+					if v == 48 {
+						name = "setab"
+					}
+					s = ti.str(name, n)
+				}
+				if s == "" {
+					s = fmt.Sprintf("\x1b[%d;5;%dm", v, n)
+				}
+				i += 2
+			case (v == 38 || v == 48) && i+4 < len(params) && params[i+1] == "2":
+				// 24-bit color "38;2;<r>;<g>;<b>"
+				s = "\x1b[" + strings.Join(params[i:i+5], ";") + "m"
+				i += 4
+			}
+			if s == "" {
+				s = fmt.Sprintf("\x1b[%dm", v)
+			}
+			out.WriteString(s)
+		}
+	}
+	return out.String()
+}
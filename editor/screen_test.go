@@ -0,0 +1,74 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestScreenOutputBytes(t *testing.T) {
+	t.Parallel()
+	e := newTestEditor(t)
+	e.screen.rows, e.screen.cols = 98, 100
+	e.filename = "file.txt"
+	for i := 0; i < 200; i++ {
+		e.editorInsertRow(len(e.rows), []byte(fmt.Sprintf("line %d\tof the text for test of screen output", i)))
+	}
+	e.dirty = false
+
+	var out bytes.Buffer
+	v := newVT(e.screen.rows+2, e.screen.cols)
+	e.out = io.MultiWriter(&out, v)
+	refresh := func() int {
+		t.Helper()
+		out.Reset()
+		if err := e.Refresh(); err != nil {
+			t.Fatal(err)
+		}
+		return out.Len()
+	}
+
+	e.editorInvalidateScreen()
+	full := refresh()
+	t.Logf("full redraw: %d bytes", full)
+
+	steps := []struct {
+		name  string
+		edit  func()
+		limit int
+	}{
+		{"no changes", func() {}, 0},
+		{"type character", func() { e.InsertChar('x') }, full / 20},
+		{"type next character", func() { e.InsertChar('y') }, full / 50},
+		{"move cursor right", func() { e.editorMoveCursor(ARROW_RIGHT) }, full / 50},
+		{"move cursor down", func() { e.editorMoveCursor(ARROW_DOWN) }, full / 50},
+		{"move cursor to bottom", func() { e.cursor.y = e.offset.row + e.screen.rows - 3 }, full / 50},
+		{"insert new line", func() { e.InsertNewLine() }, full / 15},
+		{"delete character", func() { e.DelChar() }, full / 15},
+	}
+	for _, st := range steps {
+		st.edit()
+		size := refresh()
+		t.Logf("%s: %d bytes", st.name, size)
+		if size > st.limit {
+			t.Errorf("%s: too many bytes %d > %d", st.name, size, st.limit)
+		}
+
+		// screen is the same as after full redraw
+		full := newVT(v.rows, v.cols)
+		e.out = full
+		e.editorInvalidateScreen()
+		if err := e.Refresh(); err != nil {
+			t.Fatal(err)
+		}
+		e.out = io.MultiWriter(&out, v)
+		if got, expect := v.snapshot(), full.snapshot(); got != expect {
+			t.Errorf("%s: screen differs from full redraw:%s", st.name, ShowDiff(expect, got))
+		}
+	}
+	if len(v.unknown) > 0 {
+		t.Errorf("not supported output: %q", v.unknown)
+	}
+
+}
@@ -0,0 +1,508 @@
+package editor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// key script
+//
+// Key script is the readable form of key events for tests and the key
+// recorder. One command per line, empty lines and lines started by "#"
+// are ignored:
+//
+//	type "text"              characters of Go quoted string, "\n" is Enter
+//	key <name> [x<N>]        key with modifiers, N times: Enter, Ctrl-S,
+//	                         ArrowUp, Alt-x, Shift-F5 or key code 1003
+//	paste "text"             bracketed paste
+//	mouse <button> <action> <x> <y>
+//	                         mouse event: "mouse left press 10 2"
+//	size <rows>x<cols>       window size at start, before the first key
+//	resize <rows>x<cols>     change of window size
+//	wait <duration>          pause before next key: "1.5s", "300ms"
+//	expect-line <N> "text"   line N of buffer (from 1) before next key
+//	<code>                   key code of legacy ".keys" files
+//
+// The recorder writes only pauses not less than KILO_RECORD_WAIT.
+
+const KILO_RECORD_WAIT = 500 * time.Millisecond
+
+// keyNames are names of special keys
+var keyNames = map[int]string{
+	BACKSPACE:   "Backspace",
+	'\r':        "Enter",
+	'\t':        "Tab",
+	'\x1b':      "Escape",
+	ARROW_LEFT:  "ArrowLeft",
+	ARROW_RIGHT: "ArrowRight",
+	ARROW_UP:    "ArrowUp",
+	ARROW_DOWN:  "ArrowDown",
+	DEL_KEY:     "Delete",
+	HOME_KEY:    "Home",
+	END_KEY:     "End",
+	PAGE_UP:     "PageUp",
+	PAGE_DOWN:   "PageDown",
+	INSERT_KEY:  "Insert",
+	F1_KEY:      "F1",
+	F2_KEY:      "F2",
+	F3_KEY:      "F3",
+	F4_KEY:      "F4",
+	F5_KEY:      "F5",
+	F6_KEY:      "F6",
+	F7_KEY:      "F7",
+	F8_KEY:      "F8",
+	F9_KEY:      "F9",
+	F10_KEY:     "F10",
+	F11_KEY:     "F11",
+	F12_KEY:     "F12",
+	UNKNOWN_KEY: "Unknown",
+}
+
+var modifierNames = []struct {
+	mod  Modifier
+	name string
+}{
+	{MOD_CTRL, "Ctrl-"},
+	{MOD_ALT, "Alt-"},
+	{MOD_SHIFT, "Shift-"},
+	{MOD_META, "Meta-"},
+}
+
+var mouseButtons = []string{
+	MOUSE_LEFT:       "left",
+	MOUSE_MIDDLE:     "middle",
+	MOUSE_RIGHT:      "right",
+	MOUSE_WHEEL_UP:   "wheel-up",
+	MOUSE_WHEEL_DOWN: "wheel-down",
+}
+
+var mouseActions = []string{
+	MOUSE_PRESS:   "press",
+	MOUSE_RELEASE: "release",
+	MOUSE_DRAG:    "drag",
+}
+
+// KeyScript is a parsed key script.
+type KeyScript struct {
+	keys    []Key
+	size    scriptResize           // window size at start, if not zero
+	resizes map[int]scriptResize   // window size of RESIZE_KEY by index of key
+	waits   map[int]time.Duration  // pause before key by index of key
+	expects map[int][]scriptExpect // expectations before key by index of key
+}
+
+type scriptResize struct {
+	rows, cols int
+}
+
+type scriptExpect struct {
+	line int // line of script
+	row  int // line of buffer from 1
+	text string
+}
+
+// keyName returns the name of key with modifiers.
+func keyName(k Key) string {
+	code, mod := k.Code, k.Mod
+	if 0 <= code && code < 0x20 && mod&MOD_CTRL == 0 && keyNames[code] == "" {
+		// control character
+		code, mod = code|0x40, mod|MOD_CTRL
+	}
+	var name string
+	for _, m := range modifierNames {
+		if mod&m.mod != 0 {
+			name += m.name
+		}
+	}
+	switch {
+	case keyNames[code] != "":
+		return name + keyNames[code]
+	case 0x20 < code && code < 0x7f:
+		if 'a' <= code && code <= 'z' && mod&MOD_CTRL != 0 {
+			code -= 'a' - 'A'
+		}
+		return name + string(rune(code))
+	case code == ' ':
+		return name + "Space"
+	}
+	return name + strconv.Itoa(code)
+}
+
+// parseKeyName parses the name of key with modifiers.
+func parseKeyName(s string) (k Key, err error) {
+	name := s
+next:
+	for {
+		for _, m := range modifierNames {
+			if len(name) > len(m.name) && strings.EqualFold(name[:len(m.name)], m.name) {
+				k.Mod |= m.mod
+				name = name[len(m.name):]
+				continue next
+			}
+		}
+		break
+	}
+	for code, n := range keyNames {
+		if strings.EqualFold(name, n) {
+			k.Code = code
+			return normalizeKey(k), nil
+		}
+	}
+	switch {
+	case strings.EqualFold(name, "Space"):
+		k.Code = ' '
+	case utf8.RuneCountInString(name) == 1 && name[0] < 0x80 && name[0] > 0x20:
+		k.Code = int(name[0])
+		if k.Mod&MOD_CTRL != 0 && 'A' <= k.Code && k.Code <= 'Z' {
+			k.Code += 'a' - 'A'
+		}
+	default:
+		if k.Code, err = strconv.Atoi(name); err != nil {
+			return k, fmt.Errorf("unknown key %q", s)
+		}
+	}
+	if k.Mod&MOD_CTRL != 0 && 0x40 <= k.Code && k.Code < 0x60 {
+		// Ctrl-@ and Ctrl-[ ... Ctrl-_
+		k.Code &= 0x1f
+		k.Mod &^= MOD_CTRL
+	}
+	return normalizeKey(k), nil
+}
+
+// formatKey returns the command of key script for key. Key RESIZE_KEY has
+// no command, because the window size is not in the key.
+func formatKey(k Key) string {
+	switch {
+	case k.Code == PASTE_KEY:
+		return "paste " + strconv.Quote(k.Text)
+	case k.Code == MOUSE_KEY && k.Mod == 0 &&
+		k.Mouse.Button < len(mouseButtons) && k.Mouse.Action < len(mouseActions):
+		return fmt.Sprintf("mouse %s %s %d %d", mouseButtons[k.Mouse.Button],
+			mouseActions[k.Mouse.Action], k.Mouse.X, k.Mouse.Y)
+	case k.Mod == 0 && ((0x20 <= k.Code && k.Code < 0x7f) || (0x80 <= k.Code && k.Code < 0x100)):
+		return "type " + strconv.Quote(string([]byte{byte(k.Code)}))
+	}
+	return "key " + keyName(k)
+}
+
+// appendScript appends the command of key to script. Typed characters
+// are joined with the last "type" command, repeated key is counted in the
+// last "key" command.
+func appendScript(script []byte, k Key) []byte {
+	cmd := formatKey(k)
+	last := script
+	if i := strings.LastIndexByte(strings.TrimSuffix(string(script), "\n"), '\n'); i >= 0 {
+		last = script[i+1:]
+	}
+	lastCmd := strings.TrimSpace(string(last))
+	if text, ok := scriptString(lastCmd, "type"); ok && strings.HasPrefix(cmd, "type ") {
+		script = script[:len(script)-len(last)]
+		cmd = "type " + strconv.Quote(text+string([]byte{byte(k.Code)}))
+	} else if strings.HasPrefix(cmd, "key ") && strings.HasPrefix(lastCmd, cmd) {
+		times := 1
+		if rest := lastCmd[len(cmd):]; rest != "" {
+			n, err := strconv.Atoi(strings.TrimPrefix(rest, " x"))
+			if err != nil || !strings.HasPrefix(rest, " x") {
+				return append(script, cmd+"\n"...)
+			}
+			times = n
+		}
+		script = script[:len(script)-len(last)]
+		cmd = fmt.Sprintf("%s x%d", cmd, times+1)
+	}
+	return append(script, cmd+"\n"...)
+}
+
+// scriptString returns the unquoted string argument of command line.
+func scriptString(line, command string) (string, bool) {
+	if !strings.HasPrefix(line, command+" ") {
+		return "", false
+	}
+	s, err := strconv.Unquote(strings.TrimSpace(line[len(command):]))
+	return s, err == nil
+}
+
+// ParseKeyScript parses the key script.
+func ParseKeyScript(r io.Reader) (*KeyScript, error) {
+	s := &KeyScript{
+		resizes: map[int]scriptResize{},
+		waits:   map[int]time.Duration{},
+		expects: map[int][]scriptExpect{},
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if err := s.parseLine(strings.TrimSpace(scanner.Text()), line); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	return s, scanner.Err()
+}
+
+func (s *KeyScript) parseLine(l string, line int) error {
+	if l == "" || strings.HasPrefix(l, "#") {
+		return nil
+	}
+	fields := strings.Fields(l)
+	switch fields[0] {
+	case "type", "paste":
+		text, ok := scriptString(l, fields[0])
+		if !ok {
+			return fmt.Errorf("bad quoted string: %s", l)
+		}
+		if fields[0] == "paste" {
+			s.keys = append(s.keys, Key{Code: PASTE_KEY, Text: text})
+			return nil
+		}
+		for i := 0; i < len(text); i++ {
+			c := int(text[i])
+			if c == '\n' {
+				c = '\r'
+			}
+			s.keys = append(s.keys, Key{Code: c})
+		}
+	case "key":
+		if len(fields) != 2 && len(fields) != 3 {
+			return fmt.Errorf("expected: key <name> [x<N>]")
+		}
+		k, err := parseKeyName(fields[1])
+		if err != nil {
+			return err
+		}
+		times := 1
+		if len(fields) == 3 {
+			if !strings.HasPrefix(fields[2], "x") {
+				return fmt.Errorf("bad repeat %q", fields[2])
+			}
+			if times, err = strconv.Atoi(fields[2][1:]); err != nil || times < 1 {
+				return fmt.Errorf("bad repeat %q", fields[2])
+			}
+		}
+		for ; times > 0; times-- {
+			s.keys = append(s.keys, k)
+		}
+	case "mouse":
+		if len(fields) != 5 {
+			return fmt.Errorf("expected: mouse <button> <action> <x> <y>")
+		}
+		k := Key{Code: MOUSE_KEY, Mouse: Mouse{Button: -1, Action: -1}}
+		for i, name := range mouseButtons {
+			if name == fields[1] {
+				k.Mouse.Button = i
+			}
+		}
+		for i, name := range mouseActions {
+			if name == fields[2] {
+				k.Mouse.Action = i
+			}
+		}
+		x, errX := strconv.Atoi(fields[3])
+		y, errY := strconv.Atoi(fields[4])
+		if k.Mouse.Button < 0 || k.Mouse.Action < 0 || errX != nil || errY != nil {
+			return fmt.Errorf("bad mouse event: %s", l)
+		}
+		k.Mouse.X, k.Mouse.Y = x, y
+		s.keys = append(s.keys, k)
+	case "size", "resize":
+		var r scriptResize
+		if len(fields) != 2 {
+			return fmt.Errorf("expected: %s <rows>x<cols>", fields[0])
+		}
+		if _, err := fmt.Sscanf(fields[1], "%dx%d", &r.rows, &r.cols); err != nil || r.rows < 3 || r.cols < 1 {
+			return fmt.Errorf("bad window size %q", fields[1])
+		}
+		if fields[0] == "size" {
+			if len(s.keys) > 0 || s.size.rows != 0 {
+				return fmt.Errorf("window size must be once before the first key")
+			}
+			s.size = r
+			return nil
+		}
+		s.resizes[len(s.keys)] = r
+		s.keys = append(s.keys, Key{Code: RESIZE_KEY})
+	case "wait":
+		if len(fields) != 2 {
+			return fmt.Errorf("expected: wait <duration>")
+		}
+		d, err := time.ParseDuration(fields[1])
+		if err != nil || d < 0 {
+			return fmt.Errorf("bad duration %q", fields[1])
+		}
+		s.waits[len(s.keys)] += d
+	case "expect-line":
+		if len(fields) < 3 {
+			return fmt.Errorf("expected: expect-line <N> \"text\"")
+		}
+		row, err := strconv.Atoi(fields[1])
+		if err != nil || row < 1 {
+			return fmt.Errorf("bad line number %q", fields[1])
+		}
+		text, ok := scriptString(strings.TrimSpace(l[len("expect-line"):]), fields[1])
+		if !ok {
+			return fmt.Errorf("bad quoted string: %s", l)
+		}
+		s.expects[len(s.keys)] = append(s.expects[len(s.keys)], scriptExpect{line: line, row: row, text: text})
+	default:
+		code, err := strconv.Atoi(l)
+		if err != nil {
+			return fmt.Errorf("unknown command %q", fields[0])
+		}
+		s.keys = append(s.keys, Key{Code: code})
+	}
+	return nil
+}
+
+// check verifies the expectations of buffer of e before key with index
+// pos.
+func (s *KeyScript) check(e *Editor, pos int) error {
+	for _, x := range s.expects[pos] {
+		if x.row > len(e.rows) {
+			return fmt.Errorf("line %d: expected line %d %q, but buffer has %d lines",
+				x.line, x.row, x.text, len(e.rows))
+		}
+		if got := string(e.rows[x.row-1].chars); got != x.text {
+			return fmt.Errorf("line %d: line %d is %q, expected %q", x.line, x.row, got, x.text)
+		}
+	}
+	return nil
+}
+
+// ConvertKeyScript converts the legacy key codes into key script.
+func ConvertKeyScript(r io.Reader) ([]byte, error) {
+	s, err := ParseKeyScript(r)
+	if err != nil {
+		return nil, err
+	}
+	var script []byte
+	if s.size.rows != 0 {
+		script = append(script, fmt.Sprintf("size %dx%d\n", s.size.rows, s.size.cols)...)
+	}
+	for i, k := range s.keys {
+		if d := s.waits[i]; d > 0 {
+			script = append(script, fmt.Sprintf("wait %v\n", d)...)
+		}
+		if k.Code == RESIZE_KEY {
+			script = append(script, fmt.Sprintf("resize %dx%d\n", s.resizes[i].rows, s.resizes[i].cols)...)
+			continue
+		}
+		script = appendScript(script, k)
+	}
+	return script, nil
+}
+
+// replay is the terminal, that plays back the key script with its waits
+// and window sizes. Keys of terminal are read after the end of script.
+type replay struct {
+	Terminal
+	e          *Editor
+	script     *KeyScript
+	pos        int
+	rows, cols int // recorded window size, zero for size of terminal
+}
+
+func newReplay(e *Editor, t Terminal, s *KeyScript) *replay {
+	return &replay{Terminal: t, e: e, script: s, rows: s.size.rows, cols: s.size.cols}
+}
+
+func (r *replay) ReadKey() (Key, error) {
+	if r.pos > len(r.script.keys) {
+		return r.Terminal.ReadKey()
+	}
+	if err := r.script.check(r.e, r.pos); err != nil {
+		r.e.editorSetStatusMessage("replay: %v", err)
+	}
+	if r.pos == len(r.script.keys) {
+		r.pos++
+		if r.rows != 0 {
+			// back to window size of terminal
+			r.rows, r.cols = 0, 0
+			return Key{Code: RESIZE_KEY}, nil
+		}
+		return r.Terminal.ReadKey()
+	}
+	time.Sleep(r.script.waits[r.pos])
+	k := r.script.keys[r.pos]
+	if size, ok := r.script.resizes[r.pos]; ok {
+		r.rows, r.cols = size.rows, size.cols
+	}
+	r.pos++
+	return k, nil
+}
+
+func (r *replay) WindowSize() (rows, cols int, err error) {
+	if r.rows != 0 {
+		return r.rows, r.cols, nil
+	}
+	return r.Terminal.WindowSize()
+}
+
+// recorder is the terminal, that records keys of terminal into key script.
+// Script is written to buffer and the buffer is written by flush.
+type recorder struct {
+	Terminal
+	mu   sync.Mutex
+	w    *bufio.Writer
+	line []byte           // last command, that may be joined with next key
+	now  func() time.Time // clock of waits
+	last time.Time        // time of last key
+}
+
+// newRecorder starts the key script with window size of terminal.
+func newRecorder(t Terminal, w io.Writer, now func() time.Time) (*recorder, error) {
+	rows, cols, err := t.WindowSize()
+	if err != nil {
+		return nil, err
+	}
+	r := &recorder{Terminal: t, w: bufio.NewWriter(w), now: now, last: now()}
+	fmt.Fprintf(r.w, "size %dx%d\n", rows, cols)
+	return r, nil
+}
+
+func (r *recorder) ReadKey() (Key, error) {
+	k, err := r.Terminal.ReadKey()
+	if err != nil {
+		return k, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if wait := r.now().Sub(r.last); wait >= KILO_RECORD_WAIT {
+		r.writeLine(fmt.Sprintf("wait %v\n", wait.Round(10*time.Millisecond)))
+	}
+	r.last = r.now()
+	if k.Code == RESIZE_KEY {
+		rows, cols, err := r.Terminal.WindowSize()
+		if err != nil {
+			return k, err
+		}
+		r.writeLine(fmt.Sprintf("resize %dx%d\n", rows, cols))
+		return k, nil
+	}
+	script := appendScript(r.line, k)
+	i := bytes.LastIndexByte(script[:len(script)-1], '\n') + 1
+	r.w.Write(script[:i])
+	r.line = script[i:]
+	return k, nil
+}
+
+// writeLine writes the last command and the line.
+func (r *recorder) writeLine(line string) {
+	r.w.Write(r.line)
+	r.line = nil
+	r.w.WriteString(line)
+}
+
+// flush writes the buffered script. Error of any previous write is
+// returned.
+func (r *recorder) flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeLine("")
+	return r.w.Flush()
+}
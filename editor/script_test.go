@@ -0,0 +1,168 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyName(t *testing.T) {
+	tcs := []struct {
+		k    Key
+		name string
+	}{
+		{Key{Code: 's' & 0x1f}, "Ctrl-S"},
+		{Key{Code: 0}, "Ctrl-@"},
+		{Key{Code: '\r'}, "Enter"},
+		{Key{Code: BACKSPACE}, "Backspace"},
+		{Key{Code: ARROW_UP}, "ArrowUp"},
+		{Key{Code: ARROW_RIGHT, Mod: MOD_CTRL | MOD_SHIFT}, "Ctrl-Shift-ArrowRight"},
+		{Key{Code: 'x', Mod: MOD_ALT}, "Alt-x"},
+		{Key{Code: ' ', Mod: MOD_ALT}, "Alt-Space"},
+		{Key{Code: F5_KEY, Mod: MOD_SHIFT}, "Shift-F5"},
+		{Key{Code: '\t', Mod: MOD_SHIFT}, "Shift-Tab"},
+		{Key{Code: 5000}, "5000"},
+	}
+	for _, tc := range tcs {
+		if got := keyName(tc.k); got != tc.name {
+			t.Errorf("%#v: got %q, expected %q", tc.k, got, tc.name)
+		}
+		k, err := parseKeyName(tc.name)
+		if err != nil {
+			t.Errorf("%q: %v", tc.name, err)
+		} else if k != tc.k {
+			t.Errorf("%q: got %#v, expected %#v", tc.name, k, tc.k)
+		}
+	}
+	if _, err := parseKeyName("Ctrl-NotKey"); err == nil {
+		t.Errorf("expected error for unknown key")
+	}
+}
+
+func TestParseKeyScript(t *testing.T) {
+	src := `# comment
+size 24x80
+type "ab\t"
+wait 1.5s
+key Ctrl-S
+key ArrowUp x3
+paste "a\nb"
+mouse left drag 4 2
+resize 40x120
+expect-line 2 "foo \"bar\""
+1003
+`
+	s, err := ParseKeyScript(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Key{
+		{Code: 'a'}, {Code: 'b'}, {Code: '\t'},
+		{Code: 's' & 0x1f},
+		{Code: ARROW_UP}, {Code: ARROW_UP}, {Code: ARROW_UP},
+		{Code: PASTE_KEY, Text: "a\nb"},
+		{Code: MOUSE_KEY, Mouse: Mouse{Button: MOUSE_LEFT, Action: MOUSE_DRAG, X: 4, Y: 2}},
+		{Code: RESIZE_KEY},
+		{Code: ARROW_UP},
+	}
+	if len(s.keys) != len(expect) {
+		t.Fatalf("got %d keys, expected %d: %#v", len(s.keys), len(expect), s.keys)
+	}
+	for i := range expect {
+		if s.keys[i] != expect[i] {
+			t.Errorf("key %d: got %#v, expected %#v", i, s.keys[i], expect[i])
+		}
+	}
+	if r := s.resizes[9]; r.rows != 40 || r.cols != 120 {
+		t.Errorf("resize: %v", s.resizes)
+	}
+	if s.size.rows != 24 || s.size.cols != 80 {
+		t.Errorf("size: %v", s.size)
+	}
+	if len(s.waits) != 1 || s.waits[3] != 1500*time.Millisecond {
+		t.Errorf("waits: %v", s.waits)
+	}
+	if e := s.expects[10]; len(e) != 1 || e[0].row != 2 || e[0].text != `foo "bar"` || e[0].line != 10 {
+		t.Errorf("expect: %v", s.expects)
+	}
+
+	for _, bad := range []string{
+		"type abc",
+		"key",
+		"key ArrowUp 3",
+		"mouse left jump 1 1",
+		"resize 40",
+		"size 2x80",
+		"wait 10",
+		"wait -1s",
+		"expect-line x \"a\"",
+		"jump",
+	} {
+		if _, err := ParseKeyScript(strings.NewReader("\n" + bad)); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+			t.Errorf("%q: expected error with line number, got %v", bad, err)
+		}
+	}
+	if _, err := ParseKeyScript(strings.NewReader("key Enter\nsize 24x80")); err == nil {
+		t.Errorf("expected error for size after keys")
+	}
+}
+
+func TestConvertKeyScript(t *testing.T) {
+	legacy := "104 \n105 \n13 \n1003 \n1003 \n127 \n19 \n17 \n"
+	script, err := ConvertKeyScript(strings.NewReader(legacy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `type "hi"
+key Enter
+key ArrowUp x2
+key Backspace
+key Ctrl-S
+key Ctrl-Q
+`
+	if string(script) != expect {
+		t.Fatal(ShowDiff(expect, string(script)))
+	}
+
+	// same keys
+	s1, _ := ParseKeyScript(strings.NewReader(legacy))
+	s2, _ := ParseKeyScript(strings.NewReader(string(script)))
+	if len(s1.keys) != len(s2.keys) {
+		t.Fatalf("different keys: %v %v", s1.keys, s2.keys)
+	}
+	for i := range s1.keys {
+		if s1.keys[i] != s2.keys[i] {
+			t.Errorf("key %d: %#v != %#v", i, s1.keys[i], s2.keys[i])
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+	s, err := ParseKeyScript(strings.NewReader("size 10x40\ntype \"a\"\nresize 6x20\nkey Enter\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Mock{line: []Key{{Code: 'z'}}, rows: 30, cols: 90}
+	r := newReplay(newTestEditor(t), m, s)
+	size := func(rows, cols int) {
+		t.Helper()
+		if r, c, _ := r.WindowSize(); r != rows || c != cols {
+			t.Errorf("got size %dx%d, expected %dx%d", r, c, rows, cols)
+		}
+	}
+	key := func(code int) {
+		t.Helper()
+		if k, err := r.ReadKey(); err != nil || k.Code != code {
+			t.Errorf("got key %#v %v, expected %d", k, err, code)
+		}
+	}
+	size(10, 40)
+	key('a')
+	key(RESIZE_KEY)
+	size(6, 20)
+	key('\r')
+	key(RESIZE_KEY) // back to terminal size
+	size(30, 90)
+	key('z')
+}
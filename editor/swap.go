@@ -0,0 +1,310 @@
+package editor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// swap file
+//
+// While a named buffer has unsaved changes its content is periodically
+// written to a swap file. The swap file lives next to the edited file
+// (".name.swp") or, when that directory is not writable, in the user cache
+// directory. It is removed on save and on quit, so a swap file found on
+// startup means that the previous session died, unless the process of swap
+// file is running. Swap file of running process is neither recovered nor
+// overwritten.
+//
+// Format of swap file:
+//
+//	pe-swap <pid> <cursor row> <cursor col>
+//	<buffer content>
+
+const (
+	KILO_SWAP_INTERVAL = 4 * time.Second
+	KILO_SWAP_CHANGES  = 200
+)
+
+const swapHeader = "pe-swap"
+
+// swapFileNames returns the possible locations of swap file for filename
+// in order of preference.
+func swapFileNames(filename string) (names []string) {
+	if filename == "" {
+		return nil
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		abs = filename
+	}
+	dir, base := filepath.Split(abs)
+	names = append(names, filepath.Join(dir, "."+base+".swp"))
+	if cache, err := os.UserCacheDir(); err == nil {
+		escaped := strings.Replace(abs, string(filepath.Separator), "%", -1)
+		names = append(names, filepath.Join(cache, "pe", "swap", escaped+".swp"))
+	}
+	return
+}
+
+// editorWriteSwap writes the buffer into swap file.
+func (e *Editor) editorWriteSwap() (err error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %d %d %d\n", swapHeader, os.Getpid(), e.cursor.y, e.cursor.x)
+	content, _ := e.editorRowsToString()
+	buf.WriteString(content)
+
+	for _, name := range swapFileNames(e.filename) {
+		if name != e.swap.path {
+			if s, err := readSwap(name); err == nil && swapProcessAlive(s.pid) {
+				// swap file of other session
+				continue
+			}
+		}
+		if err = os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+			continue
+		}
+		if err = ioutil.WriteFile(name, buf.Bytes(), 0600); err != nil {
+			continue
+		}
+		if e.swap.path != "" && e.swap.path != name {
+			// filename is changed by "save as"
+			os.Remove(e.swap.path)
+		}
+		e.swap.path = name
+		return nil
+	}
+	return fmt.Errorf("Cannot write swap file: %v", err)
+}
+
+// editorRemoveSwap removes swap file of current buffer, if any.
+func (e *Editor) editorRemoveSwap() {
+	if e.swap.path != "" {
+		os.Remove(e.swap.path)
+	}
+	e.swap.path = ""
+	e.swap.changes = 0
+	e.swap.last = e.now()
+}
+
+// editorUpdateSwap is called after each keypress and writes the swap file
+// when the buffer is modified and enough time or changes passed since the
+// last write.
+func (e *Editor) editorUpdateSwap() {
+	if !e.options.Swap || e.filename == "" {
+		return
+	}
+	if !e.dirty || e.hex.enable {
+		return
+	}
+	e.swap.changes++
+	if e.swap.changes < KILO_SWAP_CHANGES {
+		e.editorIdleSwap()
+		return
+	}
+	e.editorSyncSwap()
+}
+
+// editorIdleSwap writes the swap file, when keypresses are not written
+// and enough time passed since the last write. It is called while the
+// terminal has no input too, so the last changes are written without
+// next keypress.
+func (e *Editor) editorIdleSwap() {
+	if e.swap.changes == 0 || e.now().Sub(e.swap.last) < KILO_SWAP_INTERVAL {
+		return
+	}
+	e.editorSyncSwap()
+}
+
+// editorSyncSwap writes the swap file of modified buffer now.
+func (e *Editor) editorSyncSwap() {
+	if !e.options.Swap || e.filename == "" {
+		return
+	}
+	if !e.dirty || e.hex.enable {
+		return
+	}
+	if err := e.editorWriteSwap(); err != nil {
+		e.editorSetStatusMessage("%v", err)
+	}
+	e.swap.changes = 0
+	e.swap.last = e.now()
+}
+
+type swapFile struct {
+	name   string
+	pid    int
+	cursor struct{ x, y int }
+	rows   [][]byte
+}
+
+// readSwap reads and parses the swap file.
+func readSwap(name string) (s swapFile, err error) {
+	fd, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	defer fd.Close()
+	s.name = name
+
+	r := bufio.NewReader(fd)
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return s, fmt.Errorf("swap file %s: cannot read header: %v", name, err)
+	}
+	var prefix string
+	if _, err = fmt.Sscanf(header, "%s %d %d %d", &prefix, &s.pid, &s.cursor.y, &s.cursor.x); err != nil || prefix != swapHeader {
+		return s, fmt.Errorf("swap file %s: not valid header %q", name, header)
+	}
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			line = line[:len(line)-1]
+		}
+		if err == io.EOF {
+			if len(line) > 0 {
+				s.rows = append(s.rows, line)
+			}
+			break
+		}
+		if err != nil {
+			return s, err
+		}
+		s.rows = append(s.rows, line)
+	}
+	return s, nil
+}
+
+// swapProcessAlive returns true, if the process of swap file is running
+// and it is not this process. Pid of this process in swap file is left by
+// the previous boot.
+func swapProcessAlive(pid int) bool {
+	if pid == os.Getpid() {
+		return false
+	}
+	return syscall.Kill(pid, 0) == nil
+}
+
+// editorCheckSwap looks for a stale swap file of the opened file and asks
+// the user what to do with it. Swap file of running process is not
+// touched.
+func (e *Editor) editorCheckSwap() error {
+	if !e.options.Swap || e.filename == "" {
+		return nil
+	}
+	var s swapFile
+	found := false
+	for _, name := range swapFileNames(e.filename) {
+		if _, err := os.Stat(name); err != nil {
+			continue
+		}
+		var err error
+		if s, err = readSwap(name); err != nil {
+			return e.editorBrokenSwap(name, err)
+		}
+		found = true
+		break
+	}
+	if !found {
+		return nil
+	}
+	if swapProcessAlive(s.pid) {
+		e.editorSetStatusMessage("Warning!!! File is edited by running process %d, swap file %s", s.pid, s.name)
+		return nil
+	}
+
+	msg := fmt.Sprintf("Swap file %s found. (R)ecover, (D)iff, (I)gnore and delete it?", s.name)
+	e.editorSetStatusMessage("%s", msg)
+	for {
+		k, err := e.editorReadSwapKey()
+		if err != nil {
+			return err
+		}
+		switch k.Code {
+		case 'r', 'R':
+			e.rows = nil
+			for _, row := range s.rows {
+				e.editorInsertRow(len(e.rows), row)
+			}
+			e.cursor.y, e.cursor.x = s.cursor.y, s.cursor.x
+			if e.cursor.y > len(e.rows) {
+				e.cursor.y = len(e.rows)
+			}
+			e.editorMoveCursor(0) // fix cursor column
+			e.dirty, e.edited = true, true
+			e.swap.path = s.name
+			e.editorSetStatusMessage("Recovered from %s", s.name)
+			return nil
+		case 'd', 'D':
+			e.editorSetStatusMessage("%s %s", e.swapDiff(s.rows), msg)
+		case 'i', 'I', '\x1b':
+			os.Remove(s.name)
+			e.editorSetStatusMessage("Swap file %s deleted", s.name)
+			return nil
+		}
+	}
+}
+
+// editorBrokenSwap asks the user what to do with swap file, that cannot
+// be read.
+func (e *Editor) editorBrokenSwap(name string, err error) error {
+	e.editorSetStatusMessage("%v. (K)eep or (D)elete it?", err)
+	for {
+		k, err := e.editorReadSwapKey()
+		if err != nil {
+			return err
+		}
+		switch k.Code {
+		case 'k', 'K', '\x1b':
+			e.editorSetStatusMessage("Swap file %s kept", name)
+			return nil
+		case 'd', 'D':
+			os.Remove(name)
+			e.editorSetStatusMessage("Swap file %s deleted", name)
+			return nil
+		}
+	}
+}
+
+// editorReadSwapKey shows the question about swap file and reads the
+// answer.
+func (e *Editor) editorReadSwapKey() (Key, error) {
+	if err := e.Refresh(); err != nil {
+		return Key{}, err
+	}
+	return e.term.ReadKey()
+}
+
+// swapDiff returns a short description of difference between the buffer
+// and the swap file rows.
+func (e *Editor) swapDiff(rows [][]byte) string {
+	diff, first := 0, -1
+	for i := 0; i < len(rows) || i < len(e.rows); i++ {
+		if i < len(rows) && i < len(e.rows) && bytes.Equal(rows[i], e.rows[i].chars) {
+			continue
+		}
+		diff++
+		if first < 0 {
+			first = i
+		}
+	}
+	if diff == 0 {
+		return "Swap file is equal to file."
+	}
+	var file, swap string
+	if first < len(e.rows) {
+		file = string(e.rows[first].chars)
+	}
+	if first < len(rows) {
+		swap = string(rows[first])
+	}
+	return fmt.Sprintf("%d lines differ, first at line %d: file %q, swap %q.",
+		diff, first+1, file, swap)
+}
@@ -0,0 +1,164 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSwapRecover(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "file.txt")
+	if err := ioutil.WriteFile(filename, []byte("first\nsecond\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.TempFile(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	// session with unsaved changes
+	e := newTestEditor(t)
+	e.out = out
+	e.options.Swap = true
+	if err := e.Open(filename); err != nil {
+		t.Fatal(err)
+	}
+	e.cursor.y = 1
+	e.InsertChar('!')
+	if err := e.editorWriteSwap(); err != nil {
+		t.Fatal(err)
+	}
+	swap := e.swap.path
+	if _, err := os.Stat(swap); err != nil {
+		t.Fatalf("swap file is not created: %v", err)
+	}
+
+	// next session recovers the changes
+	e = newTestEditor(t)
+	e.out = out
+	e.options.Swap = true
+	e.term = &Mock{line: []Key{{Code: 'd'}, {Code: 'r'}}}
+	if err := e.Open(filename); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.editorCheckSwap(); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(e.rows[1].chars); got != "!second" {
+		t.Fatalf("not recovered row: %q", got)
+	}
+	if !e.dirty {
+		t.Fatalf("recovered buffer must be dirty")
+	}
+
+	// swap file is removed after save
+	if err := e.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(swap); !os.IsNotExist(err) {
+		t.Fatalf("swap file is not removed: %v", err)
+	}
+}
+
+func TestSwapOfOtherSession(t *testing.T) {
+	t.Parallel()
+	filename := filepath.Join(t.TempDir(), "file.txt")
+	if err := ioutil.WriteFile(filename, []byte("first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	swap := swapFileNames(filename)[0]
+	open := func(keys ...Key) *Editor {
+		t.Helper()
+		e := newTestEditor(t)
+		e.options.Swap = true
+		e.term = &Mock{line: keys}
+		if err := e.Open(filename); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.editorCheckSwap(); err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	// swap file of running process is kept and not overwritten
+	content := fmt.Sprintf("%s %d 0 0\nother\n", swapHeader, os.Getppid())
+	if err := ioutil.WriteFile(swap, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	e := open()
+	if !strings.HasPrefix(e.status.msg, "Warning!!! File is edited by running process") {
+		t.Errorf("unexpected message %q", e.status.msg)
+	}
+	e.InsertChar('!')
+	if err := e.editorWriteSwap(); err == nil {
+		defer os.Remove(e.swap.path)
+	}
+	if b, _ := ioutil.ReadFile(swap); string(b) != content || e.swap.path == swap {
+		t.Errorf("swap file of other session is overwritten: %q", b)
+	}
+
+	// broken swap file is kept or deleted by user
+	if err := ioutil.WriteFile(swap, []byte("broken"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	open(Key{Code: 'r'}, Key{Code: 'k'})
+	if _, err := os.Stat(swap); err != nil {
+		t.Errorf("broken swap file is not kept: %v", err)
+	}
+	open(Key{Code: 'd'})
+	if _, err := os.Stat(swap); !os.IsNotExist(err) {
+		t.Errorf("broken swap file is not deleted: %v", err)
+	}
+}
+
+func TestSwapIdle(t *testing.T) {
+	t.Parallel()
+	filename := filepath.Join(t.TempDir(), "file.txt")
+	if err := ioutil.WriteFile(filename, []byte("first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	e := newTestEditor(t)
+	e.options.Swap = true
+	e.now = func() time.Time { return now }
+	if err := e.Open(filename); err != nil {
+		t.Fatal(err)
+	}
+	swap := func() string {
+		b, _ := ioutil.ReadFile(swapFileNames(filename)[0])
+		return string(b[bytes.IndexByte(b, '\n')+1:])
+	}
+	defer e.editorRemoveSwap()
+
+	// the first change is written, the next one waits for interval
+	for _, c := range []byte("ab") {
+		e.InsertChar(c)
+		e.editorUpdateSwap()
+	}
+	if got := swap(); got != "afirst\n" {
+		t.Fatalf("unexpected swap %q", got)
+	}
+	e.editorIdleSwap()
+	if got := swap(); got != "afirst\n" {
+		t.Fatalf("swap %q is written before interval", got)
+	}
+	now = now.Add(KILO_SWAP_INTERVAL)
+	e.editorIdleSwap()
+	if got := swap(); got != "abfirst\n" {
+		t.Fatalf("unexpected swap %q after interval", got)
+	}
+}
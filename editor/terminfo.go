@@ -0,0 +1,671 @@
+package editor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// terminfo
+//
+// Terminal capabilities are read from the compiled terminfo database of
+// TERM, see term(5). Both the legacy format with 16-bit numbers and the
+// extended format with 32-bit numbers are supported, include the section
+// of extended (user-defined) capabilities. The built-in xterm entry is used,
+// if no entry is found.
+//
+// Bracketed paste and mouse reporting are xterm private modes without
+// standard capabilities, so they are not taken from terminfo.
+
+// terminfo is a terminal entry.
+type terminfo struct {
+	names   []string
+	bools   map[string]bool
+	numbers map[string]int
+	strings map[string]string
+	keys    map[string]int // key sequence to key code
+}
+
+// xterm is the capabilities of terminal without terminfo entry.
+var xterm = xtermTerminfo()
+
+const (
+	TERMINFO_MAGIC          = 0432  // legacy format with 16-bit numbers
+	TERMINFO_MAGIC_EXTENDED = 01036 // format with 32-bit numbers
+)
+
+// terminfoKeys are key capabilities for decodeKey
+var terminfoKeys = map[string]int{
+	"kcuu1": ARROW_UP,
+	"kcud1": ARROW_DOWN,
+	"kcuf1": ARROW_RIGHT,
+	"kcub1": ARROW_LEFT,
+	"khome": HOME_KEY,
+	"kend":  END_KEY,
+	"kpp":   PAGE_UP,
+	"knp":   PAGE_DOWN,
+	"kich1": INSERT_KEY,
+	"kdch1": DEL_KEY,
+	"kf1":   F1_KEY,
+	"kf2":   F2_KEY,
+	"kf3":   F3_KEY,
+	"kf4":   F4_KEY,
+	"kf5":   F5_KEY,
+	"kf6":   F6_KEY,
+	"kf7":   F7_KEY,
+	"kf8":   F8_KEY,
+	"kf9":   F9_KEY,
+	"kf10":  F10_KEY,
+	"kf11":  F11_KEY,
+	"kf12":  F12_KEY,
+}
+
+// xtermTerminfo returns the built-in entry of xterm.
+func xtermTerminfo() *terminfo {
+	t := &terminfo{
+		names:   []string{"xterm", "xterm terminal emulator (X Window System)"},
+		bools:   map[string]bool{"am": true, "bce": true, "km": true, "xenl": true},
+		numbers: map[string]int{"cols": 80, "lines": 24, "colors": 8, "pairs": 64},
+		strings: map[string]string{
+			"bold":  "\x1b[1m",
+			"civis": "\x1b[?25l",
+			"clear": "\x1b[H\x1b[2J",
+			"cnorm": "\x1b[?12l\x1b[?25h",
+			"cud":   "\x1b[%p1%dB",
+			"cuf":   "\x1b[%p1%dC",
+			"cup":   "\x1b[%i%p1%d;%p2%dH",
+			"dim":   "\x1b[2m",
+			"el":    "\x1b[K",
+			"kcbt":  "\x1b[Z",
+			"kcub1": "\x1bOD",
+			"kcud1": "\x1bOB",
+			"kcuf1": "\x1bOC",
+			"kcuu1": "\x1bOA",
+			"kdch1": "\x1b[3~",
+			"kend":  "\x1bOF",
+			"kf1":   "\x1bOP",
+			"kf2":   "\x1bOQ",
+			"kf3":   "\x1bOR",
+			"kf4":   "\x1bOS",
+			"kf5":   "\x1b[15~",
+			"kf6":   "\x1b[17~",
+			"kf7":   "\x1b[18~",
+			"kf8":   "\x1b[19~",
+			"kf9":   "\x1b[20~",
+			"kf10":  "\x1b[21~",
+			"kf11":  "\x1b[23~",
+			"kf12":  "\x1b[24~",
+			"khome": "\x1bOH",
+			"kich1": "\x1b[2~",
+			"knp":   "\x1b[6~",
+			"kpp":   "\x1b[5~",
+			"rev":   "\x1b[7m",
+			"ritm":  "\x1b[23m",
+			"rmcup": "\x1b[?1049l\x1b[23;0;0t",
+			"rmkx":  "\x1b[?1l\x1b>",
+			"setab": "\x1b[4%p1%dm",
+			"setaf": "\x1b[3%p1%dm",
+			"sgr0":  "\x1b(B\x1b[m",
+			"sitm":  "\x1b[3m",
+			"smcup": "\x1b[?1049h\x1b[22;0;0t",
+			"smkx":  "\x1b[?1h\x1b=",
+			"smul":  "\x1b[4m",
+			"u7":    "\x1b[6n",
+		},
+	}
+	t.initKeys()
+	return t
+}
+
+// initKeys fills the key sequences of entry.
+func (t *terminfo) initKeys() {
+	t.keys = map[string]int{}
+	for name, code := range terminfoKeys {
+		if seq := t.strings[name]; strings.HasPrefix(seq, "\x1b") && len(seq) > 1 {
+			t.keys[seq] = code
+		}
+	}
+}
+
+// str returns the string capability with parameters. Empty string is a
+// missing capability.
+func (t *terminfo) str(name string, params ...int) string {
+	s, ok := t.strings[name]
+	if !ok {
+		return ""
+	}
+	if len(params) > 0 || strings.Contains(s, "%") {
+		s = tparm(s, params...)
+	}
+	return stripPadding(s)
+}
+
+// stripPadding removes the padding "$<delay>", that is not needed for
+// terminal emulators.
+func stripPadding(s string) string {
+	for {
+		start := strings.Index(s, "$<")
+		if start < 0 {
+			return s
+		}
+		end := strings.IndexByte(s[start:], '>')
+		if end < 0 {
+			return s
+		}
+		s = s[:start] + s[start+end+1:]
+	}
+}
+
+// terminfoDirs returns the directories of terminfo database in order of
+// search.
+func terminfoDirs() (dirs []string) {
+	if dir := os.Getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	if list := os.Getenv("TERMINFO_DIRS"); list != "" {
+		for _, dir := range strings.Split(list, ":") {
+			if dir == "" {
+				// empty item is the system default
+				dir = "/usr/share/terminfo"
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+	return append(dirs, "/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo")
+}
+
+// loadTerminfo finds and reads the entry of terminal name.
+func loadTerminfo(name string) (*terminfo, error) {
+	if name == "" || strings.ContainsAny(name, "/\\") || name[0] == '.' {
+		return nil, fmt.Errorf("invalid terminal name %q", name)
+	}
+	for _, dir := range terminfoDirs() {
+		// entries are in directories by first character of name:
+		// "x/xterm" or hexadecimal "78/xterm"
+		for _, sub := range []string{name[:1], fmt.Sprintf("%02x", name[0])} {
+			data, err := ioutil.ReadFile(filepath.Join(dir, sub, name))
+			if err != nil {
+				continue
+			}
+			t, err := parseTerminfo(data)
+			if err != nil {
+				return nil, fmt.Errorf("terminfo %s: %v", name, err)
+			}
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("terminfo entry of %q is not found", name)
+}
+
+// terminfoReader reads the little-endian values of compiled entry.
+type terminfoReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *terminfoReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.data)-r.pos < n {
+		r.err = fmt.Errorf("unexpected end of entry at offset %d", r.pos)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *terminfoReader) int16() int {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return int(int16(binary.LittleEndian.Uint16(b)))
+}
+
+func (r *terminfoReader) int32() int {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return int(int32(binary.LittleEndian.Uint32(b)))
+}
+
+// align skips the byte to even offset
+func (r *terminfoReader) align() {
+	if r.pos%2 != 0 && r.pos < len(r.data) {
+		r.pos++
+	}
+}
+
+// table returns the zero terminated string at offset of string table.
+func table(strs []byte, offset int) (string, bool) {
+	if offset < 0 || len(strs) <= offset {
+		return "", false
+	}
+	end := bytes.IndexByte(strs[offset:], 0)
+	if end < 0 {
+		return "", false
+	}
+	return string(strs[offset : offset+end]), true
+}
+
+// parseTerminfo parses the compiled terminfo entry.
+func parseTerminfo(data []byte) (*terminfo, error) {
+	r := &terminfoReader{data: data}
+	number := r.int16
+	switch magic := r.int16(); magic {
+	case TERMINFO_MAGIC:
+	case TERMINFO_MAGIC_EXTENDED:
+		number = r.int32
+	default:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, fmt.Errorf("bad magic number %#o", magic)
+	}
+	namesSize, boolCount, numCount, strCount, tableSize := r.int16(), r.int16(), r.int16(), r.int16(), r.int16()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if boolCount > len(terminfoBools) || numCount > len(terminfoNumbers) || strCount > len(terminfoStrings) {
+		return nil, fmt.Errorf("too many capabilities: %d booleans, %d numbers, %d strings",
+			boolCount, numCount, strCount)
+	}
+
+	t := &terminfo{
+		bools:   map[string]bool{},
+		numbers: map[string]int{},
+		strings: map[string]string{},
+	}
+	names := r.bytes(namesSize)
+	t.names = strings.Split(string(bytes.TrimRight(names, "\x00")), "|")
+	for i, b := range r.bytes(boolCount) {
+		if b == 1 {
+			t.bools[terminfoBools[i]] = true
+		}
+	}
+	r.align()
+	for i := 0; i < numCount; i++ {
+		if v := number(); v >= 0 {
+			t.numbers[terminfoNumbers[i]] = v
+		}
+	}
+	offsets := make([]int, strCount)
+	for i := range offsets {
+		offsets[i] = r.int16()
+	}
+	strs := r.bytes(tableSize)
+	if r.err != nil {
+		return nil, r.err
+	}
+	for i, offset := range offsets {
+		if offset < 0 {
+			// absent or cancelled
+			continue
+		}
+		s, ok := table(strs, offset)
+		if !ok {
+			return nil, fmt.Errorf("bad offset %d of capability %s", offset, terminfoStrings[i])
+		}
+		t.strings[terminfoStrings[i]] = s
+	}
+
+	r.align()
+	if r.pos < len(data) {
+		if err := t.parseExtended(r, number); err != nil {
+			return nil, err
+		}
+	}
+	t.initKeys()
+	return t, nil
+}
+
+// parseExtended parses the section of extended capabilities.
+func (t *terminfo) parseExtended(r *terminfoReader, number func() int) error {
+	boolCount, numCount, strCount, _, tableSize := r.int16(), r.int16(), r.int16(), r.int16(), r.int16()
+	if r.err != nil {
+		return r.err
+	}
+	if boolCount < 0 || numCount < 0 || strCount < 0 {
+		return fmt.Errorf("bad extended capabilities header")
+	}
+	bools := r.bytes(boolCount)
+	r.align()
+	nums := make([]int, numCount)
+	for i := range nums {
+		nums[i] = number()
+	}
+	offsets := make([]int, strCount)
+	for i := range offsets {
+		offsets[i] = r.int16()
+	}
+	nameOffsets := make([]int, boolCount+numCount+strCount)
+	for i := range nameOffsets {
+		nameOffsets[i] = r.int16()
+	}
+	strs := r.bytes(tableSize)
+	if r.err != nil {
+		return r.err
+	}
+
+	// names are after the values in string table
+	namesStart := 0
+	values := make([]string, strCount)
+	for i, offset := range offsets {
+		if offset < 0 {
+			continue
+		}
+		s, ok := table(strs, offset)
+		if !ok {
+			return fmt.Errorf("bad offset %d of extended capability", offset)
+		}
+		values[i] = s
+		if end := offset + len(s) + 1; end > namesStart {
+			namesStart = end
+		}
+	}
+	name := func(i int) (string, error) {
+		s, ok := table(strs, namesStart+nameOffsets[i])
+		if !ok {
+			return "", fmt.Errorf("bad name offset %d of extended capability", nameOffsets[i])
+		}
+		return s, nil
+	}
+	for i, b := range bools {
+		n, err := name(i)
+		if err != nil {
+			return err
+		}
+		if b == 1 {
+			t.bools[n] = true
+		}
+	}
+	for i, v := range nums {
+		n, err := name(boolCount + i)
+		if err != nil {
+			return err
+		}
+		if v >= 0 {
+			t.numbers[n] = v
+		}
+	}
+	for i, offset := range offsets {
+		n, err := name(boolCount + numCount + i)
+		if err != nil {
+			return err
+		}
+		if offset >= 0 {
+			t.strings[n] = values[i]
+		}
+	}
+	return nil
+}
+
+// tparm evaluates the parameterized string s, see terminfo(5). The
+// supported subset is:
+//
+//	%%  %c  %s  %[[:]flags][width[.precision]][doxX]  %p[1-9]
+//	%P[a-z] %g[a-z]  %P[A-Z] %g[A-Z]  %'c'  %{nn}  %l  %i
+//	%+ %- %* %/ %m  %& %| %^  %= %> %<  %A %O  %! %~
+//	%? expr %t then %e else %;
+//
+// All parameters and values of stack are integers.
+func tparm(s string, params ...int) string {
+	var p [9]int
+	copy(p[:], params)
+	var stack []int
+	push := func(v int) { stack = append(stack, v) }
+	pop := func() int {
+		if len(stack) == 0 {
+			return 0
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+	b2i := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	var vars [52]int // %Pa-%Pz and %PA-%PZ
+	varIndex := func(c byte) int {
+		switch {
+		case 'a' <= c && c <= 'z':
+			return int(c - 'a')
+		case 'A' <= c && c <= 'Z':
+			return 26 + int(c-'A')
+		}
+		return -1
+	}
+
+	// skip moves i after the "%e" or "%;" of the current condition level
+	skip := func(i int, elseToo bool) int {
+		level := 0
+		for i < len(s)-1 {
+			if s[i] != '%' {
+				i++
+				continue
+			}
+			switch s[i+1] {
+			case '?':
+				level++
+			case ';':
+				if level == 0 {
+					return i + 2
+				}
+				level--
+			case 'e':
+				if level == 0 && elseToo {
+					return i + 2
+				}
+			}
+			i += 2
+		}
+		return len(s)
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+1 == len(s) {
+			out.WriteByte(s[i])
+			continue
+		}
+		i++
+		c := s[i]
+		switch c {
+		case '%':
+			out.WriteByte('%')
+		case 'c':
+			out.WriteByte(byte(pop()))
+		case 's':
+			out.WriteString(strconv.Itoa(pop()))
+		case 'p':
+			if i+1 < len(s) && '1' <= s[i+1] && s[i+1] <= '9' {
+				i++
+				push(p[s[i]-'1'])
+			}
+		case 'P':
+			if i+1 < len(s) {
+				i++
+				if v := varIndex(s[i]); v >= 0 {
+					vars[v] = pop()
+				}
+			}
+		case 'g':
+			if i+1 < len(s) {
+				i++
+				if v := varIndex(s[i]); v >= 0 {
+					push(vars[v])
+				}
+			}
+		case '\'':
+			if i+2 < len(s) {
+				push(int(s[i+1]))
+				i += 2 // character and closing quote
+			}
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return out.String()
+			}
+			v, _ := strconv.Atoi(s[i+1 : i+end])
+			push(v)
+			i += end
+		case 'l':
+			push(len(strconv.Itoa(pop())))
+		case 'i':
+			p[0]++
+			p[1]++
+		case '+', '-', '*', '/', 'm', '&', '|', '^', '=', '>', '<', 'A', 'O':
+			b, a := pop(), pop()
+			switch c {
+			case '+':
+				push(a + b)
+			case '-':
+				push(a - b)
+			case '*':
+				push(a * b)
+			case '/':
+				if b == 0 {
+					push(0)
+				} else {
+					push(a / b)
+				}
+			case 'm':
+				if b == 0 {
+					push(0)
+				} else {
+					push(a % b)
+				}
+			case '&':
+				push(a & b)
+			case '|':
+				push(a | b)
+			case '^':
+				push(a ^ b)
+			case '=':
+				push(b2i(a == b))
+			case '>':
+				push(b2i(a > b))
+			case '<':
+				push(b2i(a < b))
+			case 'A':
+				push(b2i(a != 0 && b != 0))
+			case 'O':
+				push(b2i(a != 0 || b != 0))
+			}
+		case '!':
+			push(b2i(pop() == 0))
+		case '~':
+			push(^pop())
+		case '?', ';':
+		case 't':
+			if pop() == 0 {
+				i = skip(i+1, true) - 1
+			}
+		case 'e':
+			// end of executed "then" part
+			i = skip(i+1, false) - 1
+		default:
+			// printf-like "%[[:]flags][width[.precision]][doxX]"
+			j := i
+			if s[j] == ':' {
+				j++
+			}
+			for j < len(s) && strings.IndexByte("-+# 0123456789.", s[j]) >= 0 {
+				j++
+			}
+			if j == len(s) || strings.IndexByte("doxX", s[j]) < 0 {
+				// unknown
+				out.WriteByte('%')
+				out.WriteByte(c)
+				continue
+			}
+			spec := "%" + strings.TrimPrefix(s[i:j], ":") + string(s[j])
+			fmt.Fprintf(&out, spec, pop())
+			i = j
+		}
+	}
+	return out.String()
+}
+
+var terminfoBools = []string{
+	"bw", "am", "xsb", "xhp", "xenl", "eo", "gn", "hc", "km", "hs", "in",
+	"da", "db", "mir", "msgr", "os", "eslok", "xt", "hz", "ul", "xon",
+	"nxon", "mc5i", "chts", "nrrmc", "npc", "ndscr", "ccc", "bce", "hls",
+	"xhpa", "crxm", "daisy", "xvpa", "sam", "cpix", "lpix", "OTbs",
+	"OTns", "OTnc", "OTMT", "OTNL", "OTpt", "OTxr",
+}
+
+var terminfoNumbers = []string{
+	"cols", "it", "lines", "lm", "xmc", "pb", "vt", "wsl", "nlab", "lh",
+	"lw", "ma", "wnum", "colors", "pairs", "ncv", "bufsz", "spinv",
+	"spinh", "maddr", "mjump", "mcs", "mls", "npins", "orc", "orl",
+	"orhi", "orvi", "cps", "widcs", "btns", "bitwin", "bitype", "OTug",
+	"OTdC", "OTdN", "OTdB", "OTdT", "OTkn",
+}
+
+var terminfoStrings = []string{
+	"cbt", "bel", "cr", "csr", "tbc", "clear", "el", "ed", "hpa", "cmdch",
+	"cup", "cud1", "home", "civis", "cub1", "mrcup", "cnorm", "cuf1",
+	"ll", "cuu1", "cvvis", "dch1", "dl1", "dsl", "hd", "smacs", "blink",
+	"bold", "smcup", "smdc", "dim", "smir", "invis", "prot", "rev",
+	"smso", "smul", "ech", "rmacs", "sgr0", "rmcup", "rmdc", "rmir",
+	"rmso", "rmul", "flash", "ff", "fsl", "is1", "is2", "is3", "if",
+	"ich1", "il1", "ip", "kbs", "ktbc", "kclr", "kctab", "kdch1", "kdl1",
+	"kcud1", "krmir", "kel", "ked", "kf0", "kf1", "kf10", "kf2", "kf3",
+	"kf4", "kf5", "kf6", "kf7", "kf8", "kf9", "khome", "kich1", "kil1",
+	"kcub1", "kll", "knp", "kpp", "kcuf1", "kind", "kri", "khts", "kcuu1",
+	"rmkx", "smkx", "lf0", "lf1", "lf10", "lf2", "lf3", "lf4", "lf5",
+	"lf6", "lf7", "lf8", "lf9", "rmm", "smm", "nel", "pad", "dch", "dl",
+	"cud", "ich", "indn", "il", "cub", "cuf", "rin", "cuu", "pfkey",
+	"pfloc", "pfx", "mc0", "mc4", "mc5", "rep", "rs1", "rs2", "rs3", "rf",
+	"rc", "vpa", "sc", "ind", "ri", "sgr", "hts", "wind", "ht", "tsl",
+	"uc", "hu", "iprog", "ka1", "ka3", "kb2", "kc1", "kc3", "mc5p", "rmp",
+	"acsc", "pln", "kcbt", "smxon", "rmxon", "smam", "rmam", "xonc",
+	"xoffc", "enacs", "smln", "rmln", "kbeg", "kcan", "kclo", "kcmd",
+	"kcpy", "kcrt", "kend", "kent", "kext", "kfnd", "khlp", "kmrk",
+	"kmsg", "kmov", "knxt", "kopn", "kopt", "kprv", "kprt", "krdo",
+	"kref", "krfr", "krpl", "krst", "kres", "ksav", "kspd", "kund",
+	"kBEG", "kCAN", "kCMD", "kCPY", "kCRT", "kDC", "kDL", "kslt", "kEND",
+	"kEOL", "kEXT", "kFND", "kHLP", "kHOM", "kIC", "kLFT", "kMSG", "kMOV",
+	"kNXT", "kOPT", "kPRV", "kPRT", "kRDO", "kRPL", "kRIT", "kRES",
+	"kSAV", "kSPD", "kUND", "rfi", "kf11", "kf12", "kf13", "kf14", "kf15",
+	"kf16", "kf17", "kf18", "kf19", "kf20", "kf21", "kf22", "kf23",
+	"kf24", "kf25", "kf26", "kf27", "kf28", "kf29", "kf30", "kf31",
+	"kf32", "kf33", "kf34", "kf35", "kf36", "kf37", "kf38", "kf39",
+	"kf40", "kf41", "kf42", "kf43", "kf44", "kf45", "kf46", "kf47",
+	"kf48", "kf49", "kf50", "kf51", "kf52", "kf53", "kf54", "kf55",
+	"kf56", "kf57", "kf58", "kf59", "kf60", "kf61", "kf62", "kf63", "el1",
+	"mgc", "smgl", "smgr", "fln", "sclk", "dclk", "rmclk", "cwin",
+	"wingo", "hup", "dial", "qdial", "tone", "pulse", "hook", "pause",
+	"wait", "u0", "u1", "u2", "u3", "u4", "u5", "u6", "u7", "u8", "u9",
+	"op", "oc", "initc", "initp", "scp", "setf", "setb", "cpi", "lpi",
+	"chr", "cvr", "defc", "swidm", "sdrfq", "sitm", "slm", "smicm",
+	"snlq", "snrmq", "sshm", "ssubm", "ssupm", "sum", "rwidm", "ritm",
+	"rlm", "rmicm", "rshm", "rsubm", "rsupm", "rum", "mhpa", "mcud1",
+	"mcub1", "mcuf1", "mvpa", "mcuu1", "porder", "mcud", "mcub", "mcuf",
+	"mcuu", "scs", "smgb", "smgbp", "smglp", "smgrp", "smgt", "smgtp",
+	"sbim", "scsd", "rbim", "rcsd", "subcs", "supcs", "docr", "zerom",
+	"csnm", "kmous", "minfo", "reqmp", "getm", "setaf", "setab", "pfxl",
+	"devt", "csin", "s0ds", "s1ds", "s2ds", "s3ds", "smglr", "smgtb",
+	"birep", "binel", "bicr", "colornm", "defbi", "endbi", "setcolor",
+	"slines", "dispc", "smpch", "rmpch", "smsc", "rmsc", "pctrm", "scesc",
+	"scesa", "ehhlm", "elhlm", "elohlm", "erhlm", "ethlm", "evhlm",
+	"sgr1", "slength", "OTi2", "OTrs", "OTnl", "OTbc", "OTko", "OTma",
+	"OTG2", "OTG3", "OTG1", "OTG4", "OTGR", "OTGL", "OTGU", "OTGD",
+	"OTGH", "OTGV", "OTGC", "meml", "memu", "box1",
+}
@@ -0,0 +1,103 @@
+package editor
+
+import "testing"
+
+func TestTparm(t *testing.T) {
+	setaf := "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m"
+	tcs := []struct {
+		s      string
+		params []int
+		expect string
+	}{
+		{"\x1b[%i%p1%d;%p2%dH", []int{0, 0}, "\x1b[1;1H"},
+		{"\x1b[%i%p1%d;%p2%dH", []int{9, 79}, "\x1b[10;80H"},
+		{setaf, []int{1}, "\x1b[31m"},
+		{setaf, []int{9}, "\x1b[91m"},
+		{setaf, []int{200}, "\x1b[38;5;200m"},
+		{"%p1%02d|%p1%x|%p1%:-4d|", []int{10}, "10|a|10  |"},
+		{"%p1%c%'A'%c%{66}%c", []int{'x'}, "xAB"},
+		{"%p1%Pa%ga%ga%*%d", []int{7}, "49"},
+		{"%p1%p2%>%tgt%ele%;", []int{2, 1}, "gt"},
+		{"%p1%p2%>%tgt%ele%;", []int{1, 2}, "le"},
+		{"%?%p1%t%?%p2%ta%eb%;%ec%;", []int{1, 0}, "b"},
+		{"%?%p1%t%?%p2%ta%eb%;%ec%;", []int{0, 1}, "c"},
+		{"%p1%{3}%m%d %p1%!%d %p1%~%d 100%%", []int{5}, "2 0 -6 100%"},
+	}
+	for _, tc := range tcs {
+		if got := tparm(tc.s, tc.params...); got != tc.expect {
+			t.Errorf("tparm(%q, %v) = %q, expected %q", tc.s, tc.params, got, tc.expect)
+		}
+	}
+}
+
+func TestLoadTerminfo(t *testing.T) {
+	t.Setenv("TERMINFO", "testdata/terminfo")
+
+	for _, tc := range []struct {
+		name   string
+		colors int
+	}{
+		{"pe-test", 256},              // legacy format
+		{"pe-test-direct", 0x1000000}, // 32-bit numbers
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := loadTerminfo(tc.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e.names[0] != tc.name {
+				t.Errorf("names: %q", e.names)
+			}
+			if !e.bools["am"] || e.bools["bw"] || !e.bools["Tc"] {
+				t.Errorf("booleans: %v", e.bools)
+			}
+			if e.numbers["cols"] != 80 || e.numbers["colors"] != tc.colors || e.numbers["U8"] != 1 {
+				t.Errorf("numbers: %v", e.numbers)
+			}
+			for _, c := range []struct{ got, expect string }{
+				{e.str("cup", 4, 9), "\x1b[5;10H"},
+				{e.str("el"), "\x1b[K"},
+				{e.str("setaf", 12), "\x1b[94m"},
+				{e.str("Ss", 2), "\x1b[2 q"},
+				{e.str("Smulx", 3), "\x1b[4:3m"},
+				{e.str("smcup"), ""},
+			} {
+				if c.got != c.expect {
+					t.Errorf("got %q, expected %q", c.got, c.expect)
+				}
+			}
+			if e.keys["\x1b[11~"] != F1_KEY || e.keys["\x1b[A"] != ARROW_UP {
+				t.Errorf("keys: %v", e.keys)
+			}
+		})
+	}
+
+	if _, err := loadTerminfo("not-exist"); err == nil {
+		t.Errorf("expected error for unknown terminal")
+	}
+	if _, err := parseTerminfo([]byte{0x1a, 0x01, 0x10}); err == nil {
+		t.Errorf("expected error for broken entry")
+	}
+}
+
+func TestTerminfoKeys(t *testing.T) {
+	ti := &terminfo{strings: map[string]string{"kf1": "\x1b[[A", "khome": "\x1b[1~"}}
+	ti.initKeys()
+
+	tcs := []struct {
+		in     string
+		expect Key
+		n      int
+	}{
+		{"\x1b[[A", Key{Code: F1_KEY}, 4},
+		{"\x1b[[", Key{}, 0},
+		{"\x1b[1~x", Key{Code: HOME_KEY}, 4},
+		{"\x1b[2~", Key{Code: INSERT_KEY}, 4},
+	}
+	for _, tc := range tcs {
+		k, n := ti.decodeKey([]byte(tc.in))
+		if k != tc.expect || n != tc.n {
+			t.Errorf("%q: got %#v %d, expected %#v %d", tc.in, k, n, tc.expect, tc.n)
+		}
+	}
+}
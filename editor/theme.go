@@ -0,0 +1,443 @@
+package editor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// themes
+//
+// Theme maps highlight groups into styles. Theme file has one group per
+// line, empty lines and lines started by "#" are ignored:
+//
+//	<group> [fg=<color>] [bg=<color>] [bold] [italic] [underline] [reverse]
+//
+// Color is "default", ANSI name like "blue" or "bright-red", number of
+// 256-color palette or "#rrggbb". Groups, that are not in the theme file,
+// have the style of "default" theme. Colors are degraded to the amount of
+// colors of terminal.
+//
+// Theme files "<name>.theme" are in directory "pe/themes" of user config
+// directory and override the built-in themes.
+
+const DEFAULT_THEME = "default"
+
+// highlightGroups are names of highlight groups HL_*
+var highlightGroups = [HL_COUNT]string{
+	HL_NORMAL:    "normal",
+	HL_MATCH:     "match",
+	HL_SELECTION: "selection",
+	HL_STATUS:    "status",
+	HL_MESSAGE:   "message",
+	HL_CONTROL:   "control",
+	HL_NONTEXT:   "nontext",
+}
+
+// builtinThemes are sources of built-in themes
+var builtinThemes = map[string]string{
+	"default": `
+# colors of terminal
+match     fg=blue
+selection reverse
+status    reverse
+control   reverse
+`,
+	"dark": `
+normal    fg=252
+match     fg=75 bold
+selection bg=239
+status    fg=252 bg=236 bold
+message   fg=250
+control   fg=203 reverse
+nontext   fg=241
+`,
+	"light": `
+normal    fg=235
+match     fg=25 bold
+selection bg=153
+status    fg=236 bg=252 bold
+message   fg=238
+control   fg=160 reverse
+nontext   fg=250
+`,
+	"solarized-dark": `
+normal    fg=#839496
+match     fg=#268bd2 bold
+selection fg=#93a1a1 bg=#073642
+status    fg=#93a1a1 bg=#073642 bold
+message   fg=#93a1a1
+control   fg=#dc322f reverse
+nontext   fg=#586e75
+`,
+	"solarized-light": `
+normal    fg=#657b83
+match     fg=#268bd2 bold
+selection fg=#586e75 bg=#eee8d5
+status    fg=#586e75 bg=#eee8d5 bold
+message   fg=#586e75
+control   fg=#dc322f reverse
+nontext   fg=#93a1a1
+`,
+}
+
+const (
+	COLOR_DEFAULT = iota // color of terminal
+	COLOR_ANSI           // 16 colors 0-15
+	COLOR_256            // 256-color palette
+	COLOR_RGB            // 24-bit color 0xrrggbb
+)
+
+type color struct {
+	kind  int
+	value int
+}
+
+type style struct {
+	fg, bg    color
+	bold      bool
+	italic    bool
+	underline bool
+	reverse   bool
+}
+
+// ansiColors are names of 16 ANSI colors
+var ansiColors = [16]string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"bright-black", "bright-red", "bright-green", "bright-yellow",
+	"bright-blue", "bright-magenta", "bright-cyan", "bright-white",
+}
+
+// ansiRGB are xterm values of 16 ANSI colors
+var ansiRGB = [16]int{
+	0x000000, 0xcd0000, 0x00cd00, 0xcdcd00, 0x0000ee, 0xcd00cd, 0x00cdcd, 0xe5e5e5,
+	0x7f7f7f, 0xff0000, 0x00ff00, 0xffff00, 0x5c5cff, 0xff00ff, 0x00ffff, 0xffffff,
+}
+
+// defaultHighlight are SGR sequences of highlight groups of default theme
+var defaultHighlight = func() (highlight [HL_COUNT]string) {
+	t, err := parseTheme(strings.NewReader(builtinThemes[DEFAULT_THEME]), DEFAULT_THEME)
+	if err != nil {
+		panic(err)
+	}
+	for i, s := range t {
+		highlight[i] = s.sgr(8)
+	}
+	return
+}()
+
+// highlightGroup returns HL_* of highlight group name.
+func highlightGroup(name string) (int, bool) {
+	for i, g := range highlightGroups {
+		if g == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// parseColor parses the color of theme.
+func parseColor(s string) (c color, err error) {
+	if s == "default" {
+		return color{}, nil
+	}
+	for i, name := range ansiColors {
+		if s == name {
+			return color{kind: COLOR_ANSI, value: i}, nil
+		}
+	}
+	if strings.HasPrefix(s, "#") {
+		v, err := strconv.ParseUint(s[1:], 16, 32)
+		if err != nil || len(s) != 7 {
+			return c, fmt.Errorf("bad color %q", s)
+		}
+		return color{kind: COLOR_RGB, value: int(v)}, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 0 || 255 < v {
+		return c, fmt.Errorf("bad color %q", s)
+	}
+	return color{kind: COLOR_256, value: v}, nil
+}
+
+// parseTheme parses the theme file. Missing groups have the style of
+// default theme.
+func parseTheme(r io.Reader, name string) (t [HL_COUNT]style, err error) {
+	if name != DEFAULT_THEME {
+		if t, err = parseTheme(strings.NewReader(builtinThemes[DEFAULT_THEME]), DEFAULT_THEME); err != nil {
+			return
+		}
+	}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		group, ok := highlightGroup(fields[0])
+		if !ok {
+			return t, fmt.Errorf("%s:%d: unknown highlight group %q", name, line, fields[0])
+		}
+		s, err := parseStyle(fields[1:])
+		if err != nil {
+			return t, fmt.Errorf("%s:%d: %v", name, line, err)
+		}
+		t[group] = s
+	}
+	return t, scanner.Err()
+}
+
+// parseStyle parses the attributes of highlight group.
+func parseStyle(fields []string) (s style, err error) {
+	for _, f := range fields {
+		switch {
+		case f == "bold":
+			s.bold = true
+		case f == "italic":
+			s.italic = true
+		case f == "underline":
+			s.underline = true
+		case f == "reverse":
+			s.reverse = true
+		case strings.HasPrefix(f, "fg="):
+			s.fg, err = parseColor(f[3:])
+		case strings.HasPrefix(f, "bg="):
+			s.bg, err = parseColor(f[3:])
+		default:
+			err = fmt.Errorf("unknown attribute %q", f)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// themeDir returns directory of theme files.
+func themeDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pe", "themes"), nil
+}
+
+// ThemeNames returns names of built-in themes and theme files.
+func ThemeNames() (names []string) {
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	if dir, err := themeDir(); err == nil {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.theme"))
+		for _, f := range files {
+			name := strings.TrimSuffix(filepath.Base(f), ".theme")
+			if _, ok := builtinThemes[name]; !ok {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+// loadTheme reads the theme file or the built-in theme.
+func loadTheme(name string) (t [HL_COUNT]style, err error) {
+	if dir, err := themeDir(); err == nil && !strings.ContainsAny(name, `/\`) {
+		content, err := ioutil.ReadFile(filepath.Join(dir, name+".theme"))
+		if err == nil {
+			return parseTheme(strings.NewReader(string(content)), name+".theme")
+		}
+		if !os.IsNotExist(err) {
+			return t, err
+		}
+	}
+	src, ok := builtinThemes[name]
+	if !ok {
+		return t, fmt.Errorf("unknown theme %q. Supported: %s", name, strings.Join(ThemeNames(), ", "))
+	}
+	return parseTheme(strings.NewReader(src), name)
+}
+
+// editorSetTheme switches the highlight groups into theme name for
+// colors of terminal.
+func (e *Editor) editorSetTheme(name string) error {
+	t, err := loadTheme(name)
+	if err != nil {
+		return err
+	}
+	for group, s := range e.settings.colors {
+		t[group] = s
+	}
+	colors := e.terminalColors()
+	for i, s := range t {
+		e.highlight[i] = s.sgr(colors)
+	}
+	return nil
+}
+
+// editorUpdateTheme sets the theme of options or of config file. Colors of
+// default theme are kept, if neither theme nor colors are configured.
+func (e *Editor) editorUpdateTheme() error {
+	name := e.options.Theme
+	if name == "" {
+		name = e.settings.theme
+	}
+	if name == "" && len(e.settings.colors) == 0 {
+		return nil
+	}
+	if name == "" {
+		name = DEFAULT_THEME
+	}
+	return e.editorSetTheme(name)
+}
+
+// terminalColors returns the amount of terminal colors by COLORTERM and
+// terminfo entry.
+func (e *Editor) terminalColors() int {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return 1 << 24
+	}
+	if e.ti.bools["Tc"] || e.ti.bools["RGB"] {
+		return 1 << 24
+	}
+	return e.ti.numbers["colors"]
+}
+
+// degrade converts the color into color supported by terminal with amount
+// of colors.
+func (c color) degrade(colors int) color {
+	if c.kind == COLOR_RGB && colors < 1<<24 {
+		c = color{kind: COLOR_256, value: rgbTo256(c.value)}
+	}
+	if c.kind == COLOR_256 && colors < 256 {
+		c = color{kind: COLOR_ANSI, value: nearestANSI(palette256(c.value))}
+	}
+	if c.kind == COLOR_ANSI && colors < 16 {
+		c.value &= 7
+	}
+	if c.kind == COLOR_ANSI && colors < 8 {
+		c = color{}
+	}
+	return c
+}
+
+// sgr returns SGR parameters of the color. Base is 30 for foreground and
+// 40 for background.
+func (c color) sgr(base int) string {
+	switch c.kind {
+	case COLOR_ANSI:
+		if c.value < 8 {
+			return strconv.Itoa(base + c.value)
+		}
+		return strconv.Itoa(base + 60 + c.value - 8)
+	case COLOR_256:
+		return fmt.Sprintf("%d;5;%d", base+8, c.value)
+	case COLOR_RGB:
+		return fmt.Sprintf("%d;2;%d;%d;%d", base+8, c.value>>16, c.value>>8&0xff, c.value&0xff)
+	}
+	return ""
+}
+
+// sgr returns the SGR sequence of style for terminal with amount of
+// colors. Sequence resets all previous attributes.
+func (s style) sgr(colors int) string {
+	var params []string
+	if s.bold {
+		params = append(params, "1")
+	}
+	if s.italic {
+		params = append(params, "3")
+	}
+	if s.underline {
+		params = append(params, "4")
+	}
+	if s.reverse {
+		params = append(params, "7")
+	}
+	if p := s.fg.degrade(colors).sgr(30); p != "" {
+		params = append(params, p)
+	}
+	if p := s.bg.degrade(colors).sgr(40); p != "" {
+		params = append(params, p)
+	}
+	if len(params) == 0 {
+		return "\x1b[m"
+	}
+	return "\x1b[0;" + strings.Join(params, ";") + "m"
+}
+
+// cube6 are the levels of 6x6x6 color cube of 256-color palette
+var cube6 = [6]int{0, 0x5f, 0x87, 0xaf, 0xd7, 0xff}
+
+// palette256 returns 0xrrggbb of color of 256-color palette.
+func palette256(v int) int {
+	switch {
+	case v < 16:
+		return ansiRGB[v]
+	case v < 232:
+		v -= 16
+		return cube6[v/36]<<16 | cube6[v/6%6]<<8 | cube6[v%6]
+	}
+	gray := 8 + (v-232)*10
+	return gray<<16 | gray<<8 | gray
+}
+
+// rgbDistance returns the squared distance between colors.
+func rgbDistance(a, b int) int {
+	dr := a>>16 - b>>16
+	dg := a>>8&0xff - b>>8&0xff
+	db := a&0xff - b&0xff
+	return dr*dr + dg*dg + db*db
+}
+
+// rgbTo256 returns the nearest color of 256-color palette, color cube or
+// grayscale.
+func rgbTo256(rgb int) int {
+	level := func(v int) int {
+		best := 0
+		for i, l := range cube6 {
+			if abs(v-l) < abs(v-cube6[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	r, g, b := rgb>>16, rgb>>8&0xff, rgb&0xff
+	best := 16 + 36*level(r) + 6*level(g) + level(b)
+	gray := (r + g + b) / 3
+	if gray > 238 {
+		gray = 238
+	}
+	if gray < 8 {
+		gray = 8
+	}
+	if g := 232 + (gray-8+5)/10; rgbDistance(palette256(g), rgb) < rgbDistance(palette256(best), rgb) {
+		best = g
+	}
+	return best
+}
+
+// nearestANSI returns the nearest ANSI color.
+func nearestANSI(rgb int) int {
+	best := 0
+	for i, c := range ansiRGB {
+		if rgbDistance(c, rgb) < rgbDistance(ansiRGB[best], rgb) {
+			best = i
+		}
+	}
+	return best
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
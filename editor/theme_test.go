@@ -0,0 +1,114 @@
+package editor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinThemes(t *testing.T) {
+	for name, src := range builtinThemes {
+		if _, err := parseTheme(strings.NewReader(src), name); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestThemeSGR(t *testing.T) {
+	s := style{bold: true, fg: color{kind: COLOR_RGB, value: 0x268bd2}, bg: color{kind: COLOR_256, value: 236}}
+	tcs := []struct {
+		colors int
+		expect string
+	}{
+		{1 << 24, "\x1b[0;1;38;2;38;139;210;48;5;236m"},
+		{256, "\x1b[0;1;38;5;32;48;5;236m"},
+		{16, "\x1b[0;1;36;40m"},
+		{8, "\x1b[0;1;36;40m"},
+		{0, "\x1b[0;1m"},
+	}
+	for _, tc := range tcs {
+		if got := s.sgr(tc.colors); got != tc.expect {
+			t.Errorf("%d colors: got %q, expected %q", tc.colors, got, tc.expect)
+		}
+	}
+	if got := (style{}).sgr(256); got != "\x1b[m" {
+		t.Errorf("empty style: %q", got)
+	}
+}
+
+func TestParseThemeErrors(t *testing.T) {
+	tcs := []struct {
+		src, err string
+	}{
+		{"normal fg=red\n\nfoo bold", "x:3: unknown highlight group \"foo\""},
+		{"# comment\nmatch fg=256", "x:2: bad color \"256\""},
+		{"match fg=#12345", "x:1: bad color \"#12345\""},
+		{"match blink", "x:1: unknown attribute \"blink\""},
+	}
+	for _, tc := range tcs {
+		_, err := parseTheme(strings.NewReader(tc.src), "x")
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("%q: got error %v, expected %q", tc.src, err, tc.err)
+		}
+	}
+}
+
+func TestThemeFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("COLORTERM", "")
+
+	themes := filepath.Join(dir, "pe", "themes")
+	if err := os.MkdirAll(themes, 0755); err != nil {
+		t.Fatal(err)
+	}
+	src := "match fg=bright-green underline\n"
+	if err := ioutil.WriteFile(filepath.Join(themes, "my.theme"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, name := range ThemeNames() {
+		found = found || name == "my"
+	}
+	if !found {
+		t.Errorf("theme file is not in list: %v", ThemeNames())
+	}
+
+	e := newTestEditor(t)
+	if err := e.editorSetTheme("my"); err != nil {
+		t.Fatal(err)
+	}
+	// xterm has 8 colors
+	if e.highlight[HL_MATCH] != "\x1b[0;4;32m" || e.highlight[HL_STATUS] != "\x1b[0;7m" {
+		t.Errorf("unexpected highlight: %q", e.highlight)
+	}
+	if err := e.editorSetTheme("not-exist"); err == nil {
+		t.Errorf("expected error for unknown theme")
+	}
+}
+
+func TestTerminfoAttr(t *testing.T) {
+	t.Setenv("TERMINFO", "testdata/terminfo")
+	ti, err := loadTerminfo("pe-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcs := []struct {
+		attr, expect string
+	}{
+		{"\x1b[7;31m", "\x1b[7m\x1b[31m"},
+		{"\x1b[1;38;5;200;48;2;1;2;3m", "\x1b[1m\x1b[38;5;200m\x1b[48;2;1;2;3m"},
+		{"\x1b[92m", "\x1b[92m"},
+	}
+	for _, tc := range tcs {
+		if got := ti.attr(tc.attr); got != tc.expect {
+			t.Errorf("%q: got %q, expected %q", tc.attr, got, tc.expect)
+		}
+	}
+}
@@ -0,0 +1,479 @@
+package editor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// vt is a small VT100/xterm emulator for tests. It interprets the output
+// of editor into the grid of characters with attributes. Not supported
+// sequences are collected in unknown.
+type vt struct {
+	rows, cols int
+	main, alt  [][]vtCell
+	grid       [][]vtCell // current screen: main or alternate
+	altScreen  bool       // alternate screen is current
+	y, x       int
+	wrap       bool // pending wrap after the last column
+	attr       vtAttr
+	saved      struct{ y, x int }
+	modes      map[string]bool // modes of "CSI h" and "CSI l" like "?25"
+	pending    []byte          // not finished sequence or character
+	unknown    []string
+}
+
+type vtAttr struct {
+	bold, italic, underline, reverse bool
+	fg, bg                           string // SGR color parameters, like "4", "5;200"
+}
+
+type vtCell struct {
+	ch   rune
+	attr vtAttr
+}
+
+func newVT(rows, cols int) *vt {
+	v := &vt{rows: rows, cols: cols, modes: map[string]bool{"?25": true}}
+	v.main = v.newGrid()
+	v.alt = v.newGrid()
+	v.grid = v.main
+	return v
+}
+
+// resize changes the size of screen. Content of screen is kept from top
+// left corner.
+func (v *vt) resize(rows, cols int) {
+	resize := func(old [][]vtCell) [][]vtCell {
+		g := v.newGrid()
+		for y := 0; y < len(g) && y < len(old); y++ {
+			copy(g[y], old[y])
+		}
+		return g
+	}
+	v.rows, v.cols = rows, cols
+	v.main, v.alt = resize(v.main), resize(v.alt)
+	v.grid = v.main
+	if v.altScreen {
+		v.grid = v.alt
+	}
+	v.move(v.y, v.x)
+}
+
+func (v *vt) newGrid() [][]vtCell {
+	g := make([][]vtCell, v.rows)
+	for y := range g {
+		g[y] = make([]vtCell, v.cols)
+		for x := range g[y] {
+			g[y][x].ch = ' '
+		}
+	}
+	return g
+}
+
+// clear clears cells [from, to) of row y by current background.
+func (v *vt) clear(y, from, to int) {
+	for x := from; x < to && x < v.cols; x++ {
+		v.grid[y][x] = vtCell{ch: ' ', attr: vtAttr{bg: v.attr.bg}}
+	}
+}
+
+func (v *vt) newline() {
+	if v.y < v.rows-1 {
+		v.y++
+		return
+	}
+	copy(v.grid, v.grid[1:])
+	v.grid[v.rows-1] = make([]vtCell, v.cols)
+	v.clear(v.rows-1, 0, v.cols)
+}
+
+func (v *vt) move(y, x int) {
+	if y < 0 {
+		y = 0
+	}
+	if y >= v.rows {
+		y = v.rows - 1
+	}
+	if x < 0 {
+		x = 0
+	}
+	if x >= v.cols {
+		x = v.cols - 1
+	}
+	v.y, v.x, v.wrap = y, x, false
+}
+
+func (v *vt) Write(p []byte) (n int, err error) {
+	b := append(v.pending, p...)
+	v.pending = nil
+	for len(b) > 0 {
+		used := v.process(b)
+		if used == 0 {
+			v.pending = append([]byte(nil), b...)
+			break
+		}
+		b = b[used:]
+	}
+	return len(p), nil
+}
+
+// process interprets the first character or sequence of b and returns
+// the amount of used bytes. Zero is not finished sequence.
+func (v *vt) process(b []byte) int {
+	switch c := b[0]; {
+	case c == '\x1b':
+		return v.escape(b)
+	case c == '\r':
+		v.x, v.wrap = 0, false
+	case c == '\n':
+		v.newline()
+		v.wrap = false
+	case c == '\b':
+		if v.x > 0 {
+			v.x--
+		}
+		v.wrap = false
+	case c == '\t':
+		v.move(v.y, (v.x/8+1)*8)
+	case c < 0x20 || c == 0x7f:
+		// bell, shift in/out
+	default:
+		if !utf8.FullRune(b) {
+			return 0
+		}
+		r, size := utf8.DecodeRune(b)
+		if v.wrap {
+			v.x, v.wrap = 0, false
+			v.newline()
+		}
+		v.grid[v.y][v.x] = vtCell{ch: r, attr: v.attr}
+		if v.x == v.cols-1 {
+			v.wrap = true
+		} else {
+			v.x++
+		}
+		return size
+	}
+	return 1
+}
+
+func (v *vt) escape(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+	switch b[1] {
+	case '[':
+		i := 2
+		for i < len(b) && 0x20 <= b[i] && b[i] <= 0x3f {
+			i++
+		}
+		if i == len(b) {
+			return 0
+		}
+		v.csi(string(b[2:i]), b[i])
+		return i + 1
+	case ']':
+		// operating system command up to BEL or ST
+		for i := 2; i < len(b); i++ {
+			if b[i] == '\a' {
+				return i + 1
+			}
+			if b[i] == '\x1b' && i+1 < len(b) && b[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return 0
+	case '(', ')':
+		// character set
+		if len(b) < 3 {
+			return 0
+		}
+		return 3
+	case '=', '>':
+		// keypad mode
+	case '7':
+		v.saved.y, v.saved.x = v.y, v.x
+	case '8':
+		v.move(v.saved.y, v.saved.x)
+	default:
+		v.unknown = append(v.unknown, fmt.Sprintf("%q", b[:2]))
+	}
+	return 2
+}
+
+func (v *vt) csi(params string, final byte) {
+	private := strings.HasPrefix(params, "?")
+	var ps []int
+	if params != "" && !private {
+		for _, p := range strings.Split(params, ";") {
+			n, _ := strconv.Atoi(p)
+			ps = append(ps, n)
+		}
+	}
+	param := func(i, def int) int {
+		if i < len(ps) && ps[i] != 0 {
+			return ps[i]
+		}
+		return def
+	}
+	switch final {
+	case 'H', 'f':
+		v.move(param(0, 1)-1, param(1, 1)-1)
+	case 'A':
+		v.move(v.y-param(0, 1), v.x)
+	case 'B':
+		v.move(v.y+param(0, 1), v.x)
+	case 'C':
+		v.move(v.y, v.x+param(0, 1))
+	case 'D':
+		v.move(v.y, v.x-param(0, 1))
+	case 'G':
+		v.move(v.y, param(0, 1)-1)
+	case 'd':
+		v.move(param(0, 1)-1, v.x)
+	case 'J':
+		switch param(0, 0) {
+		case 0:
+			v.clear(v.y, v.x, v.cols)
+			for y := v.y + 1; y < v.rows; y++ {
+				v.clear(y, 0, v.cols)
+			}
+		case 1:
+			v.clear(v.y, 0, v.x+1)
+			for y := 0; y < v.y; y++ {
+				v.clear(y, 0, v.cols)
+			}
+		default:
+			for y := 0; y < v.rows; y++ {
+				v.clear(y, 0, v.cols)
+			}
+		}
+	case 'K':
+		switch param(0, 0) {
+		case 0:
+			v.clear(v.y, v.x, v.cols)
+		case 1:
+			v.clear(v.y, 0, v.x+1)
+		default:
+			v.clear(v.y, 0, v.cols)
+		}
+	case 'm':
+		v.sgr(params)
+	case 'h', 'l':
+		for _, m := range strings.Split(strings.TrimPrefix(params, "?"), ";") {
+			if private {
+				m = "?" + m
+			}
+			v.modes[m] = final == 'h'
+			if m == "?1049" {
+				v.switchScreen(final == 'h')
+			}
+		}
+	case 'n', 't', 'c', 'q':
+		// reports, window operations, cursor style
+	default:
+		v.unknown = append(v.unknown, fmt.Sprintf("%q", "\x1b["+params+string(final)))
+	}
+}
+
+// switchScreen switches between main and cleared alternate screen.
+func (v *vt) switchScreen(alt bool) {
+	v.altScreen = alt
+	if alt {
+		v.saved.y, v.saved.x = v.y, v.x
+		v.alt = v.newGrid()
+		v.grid = v.alt
+		return
+	}
+	v.grid = v.main
+	v.move(v.saved.y, v.saved.x)
+}
+
+func (v *vt) sgr(params string) {
+	ps := strings.Split(params, ";")
+	for i := 0; i < len(ps); i++ {
+		n, err := strconv.Atoi(ps[i])
+		if ps[i] == "" {
+			n, err = 0, nil
+		}
+		if err != nil {
+			v.unknown = append(v.unknown, "SGR "+params)
+			return
+		}
+		switch {
+		case n == 0:
+			v.attr = vtAttr{}
+		case n == 1:
+			v.attr.bold = true
+		case n == 3:
+			v.attr.italic = true
+		case n == 4:
+			v.attr.underline = true
+		case n == 7:
+			v.attr.reverse = true
+		case n == 22:
+			v.attr.bold = false
+		case n == 23:
+			v.attr.italic = false
+		case n == 24:
+			v.attr.underline = false
+		case n == 27:
+			v.attr.reverse = false
+		case 30 <= n && n <= 37:
+			v.attr.fg = strconv.Itoa(n - 30)
+		case 40 <= n && n <= 47:
+			v.attr.bg = strconv.Itoa(n - 40)
+		case 90 <= n && n <= 97:
+			v.attr.fg = strconv.Itoa(n - 90 + 8)
+		case 100 <= n && n <= 107:
+			v.attr.bg = strconv.Itoa(n - 100 + 8)
+		case n == 39:
+			v.attr.fg = ""
+		case n == 49:
+			v.attr.bg = ""
+		case (n == 38 || n == 48) && i+2 < len(ps) && ps[i+1] == "5":
+			c := "5;" + ps[i+2]
+			i += 2
+			if n == 38 {
+				v.attr.fg = c
+			} else {
+				v.attr.bg = c
+			}
+		case (n == 38 || n == 48) && i+4 < len(ps) && ps[i+1] == "2":
+			c := strings.Join(ps[i+1:i+5], ";")
+			i += 4
+			if n == 38 {
+				v.attr.fg = c
+			} else {
+				v.attr.bg = c
+			}
+		default:
+			v.unknown = append(v.unknown, "SGR "+params)
+			return
+		}
+	}
+}
+
+func (a vtAttr) String() string {
+	var s []string
+	for _, f := range []struct {
+		on   bool
+		name string
+	}{
+		{a.bold, "bold"}, {a.italic, "italic"}, {a.underline, "underline"}, {a.reverse, "reverse"},
+	} {
+		if f.on {
+			s = append(s, f.name)
+		}
+	}
+	if a.fg != "" {
+		s = append(s, "fg="+a.fg)
+	}
+	if a.bg != "" {
+		s = append(s, "bg="+a.bg)
+	}
+	return strings.Join(s, " ")
+}
+
+// snapshot returns the screen as text:
+//
+//	cursor <y> <x>
+//	|<row>               rows without trailing spaces
+//	attr <y> |<cells>    rows with attributes, cell is letter of style
+//	style <letter> <attributes>
+func (v *vt) snapshot() string {
+	var out strings.Builder
+	if v.modes["?25"] {
+		fmt.Fprintf(&out, "cursor %d %d\n", v.y, v.x)
+	} else {
+		out.WriteString("cursor hidden\n")
+	}
+	for _, row := range v.grid {
+		var line strings.Builder
+		for _, c := range row {
+			line.WriteRune(c.ch)
+		}
+		fmt.Fprintf(&out, "|%s\n", strings.TrimRight(line.String(), " "))
+	}
+	var styles []vtAttr
+	for y, row := range v.grid {
+		var line []byte
+		for _, c := range row {
+			if c.attr == (vtAttr{}) {
+				line = append(line, ' ')
+				continue
+			}
+			index := -1
+			for i, s := range styles {
+				if s == c.attr {
+					index = i
+				}
+			}
+			if index < 0 {
+				index = len(styles)
+				styles = append(styles, c.attr)
+			}
+			line = append(line, byte('a'+index))
+		}
+		if s := strings.TrimRight(string(line), " "); s != "" {
+			fmt.Fprintf(&out, "attr %d |%s\n", y, s)
+		}
+	}
+	for i, s := range styles {
+		fmt.Fprintf(&out, "style %c %s\n", 'a'+i, s)
+	}
+	return out.String()
+}
+
+// screenFile is the golden file "N.screen" with snapshots of screen:
+//
+//	size <rows>x<cols>
+//	=== key <N>          screen before reading of key N
+//	<snapshot>
+//	=== final            screen after exit of editor
+//	<snapshot>
+type screenFile struct {
+	rows, cols int
+	steps      []string          // "key N" or "final"
+	snapshots  map[string]string // step to snapshot
+}
+
+func parseScreenFile(content string) (f screenFile, err error) {
+	f.snapshots = map[string]string{}
+	step := ""
+	for i, line := range strings.SplitAfter(content, "\n") {
+		switch {
+		case line == "":
+		case i == 0 && strings.HasPrefix(line, "size "):
+			if _, err := fmt.Sscanf(line, "size %dx%d\n", &f.rows, &f.cols); err != nil {
+				return f, fmt.Errorf("line %d: %v", i+1, err)
+			}
+		case strings.HasPrefix(line, "=== "):
+			step = strings.TrimSpace(line[4:])
+			var n int
+			if _, err := fmt.Sscanf(step, "key %d", &n); err != nil && step != "final" {
+				return f, fmt.Errorf("line %d: unknown step %q", i+1, step)
+			}
+			f.steps = append(f.steps, step)
+			f.snapshots[step] = ""
+		case step == "":
+			return f, fmt.Errorf("line %d: snapshot without step", i+1)
+		default:
+			f.snapshots[step] += line
+		}
+	}
+	return
+}
+
+func (f screenFile) String() string {
+	var out strings.Builder
+	if f.rows != 0 {
+		fmt.Fprintf(&out, "size %dx%d\n", f.rows, f.cols)
+	}
+	for _, step := range f.steps {
+		fmt.Fprintf(&out, "=== %s\n%s", step, f.snapshots[step])
+	}
+	return out.String()
+}
@@ -0,0 +1,3 @@
+module github.com/Konstantin8105/pe
+
+go 1.18
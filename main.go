@@ -0,0 +1,194 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Konstantin8105/pe/editor"
+)
+
+// flags
+var key = struct {
+	store    *bool
+	filename string // path of keys filename
+	text     string // path of text filename
+}{}
+
+func main() {
+	var options editor.Options
+
+	// flag
+	key.store = flag.Bool("kr", false, "Debug tool for keys record and save file result.\n"+
+		"Files(keys, text) are save in folder './editor/testdata/'. Content of edited file is saved as input.")
+	convert := flag.String("kconvert", "", "Debug tool for convert of legacy keys file with key codes into key script.\n"+
+		"Key script is written to stdout.")
+	replay := flag.String("replay", "", "Play back the recorded key script with its waits and window sizes.\n"+
+		"Initial file '<name>.input' of script '<name>.keys' is edited in temporary directory.")
+	scriptFile := flag.String("script", "", "Run the key script against the file without terminal and save the file.\n"+
+		"Exit status is nonzero, if expectation of script fails or file is not saved.")
+	filename := flag.String("e", "", "Edit file. File may be also given as argument.\n"+
+		"Filename '-' reads the buffer from stdin.")
+	flag.BoolVar(&options.Stdout, "stdout", false, "Write the buffer to stdout on quit.\n"+
+		"Enabled by default, if buffer is read from stdin and stdout is not a terminal.")
+	flag.StringVar(&options.Encoding, "encoding", editor.DEFAULT_LEGACY_ENCODING, "Legacy encoding of files, that are not valid UTF-8.\n"+
+		"Supported: "+strings.Join(editor.LegacyEncodingNames(), ", "))
+	flag.DurationVar(&options.EscapeTimeout, "esctimeout", editor.KILO_ESCAPE_TIMEOUT,
+		"Waiting time of escape sequence rest after Escape key.")
+	flag.StringVar(&options.Theme, "theme", "", "Color theme. Theme files '<name>.theme' are in 'pe/themes' of user config directory.\n"+
+		"By default the theme of config file or '"+editor.DEFAULT_THEME+"'. Supported: "+strings.Join(editor.ThemeNames(), ", "))
+	flag.BoolVar(&options.Config, "config", true, "Read config file 'pe/"+editor.CONFIG_FILE+"' of user config directory and\n"+
+		"project config files '"+editor.PROJECT_CONFIG_FILE+"', '"+editor.EDITORCONFIG_FILE+"' in directory of file and its parents.")
+	flag.BoolVar(&options.Mouse, "mouse", true, "Enable mouse: click, selection and wheel scrolling.")
+	flag.BoolVar(&options.Swap, "swap", true, "Write swap file for crash recovery.")
+	flag.BoolVar(&options.Backup.Enable, "backup", false, "Keep previous version of file on save as 'file~'.")
+	flag.StringVar(&options.Backup.Dir, "backupdir", "", "Directory for numbered backups instead of 'file~'.")
+	flag.IntVar(&options.Backup.Keep, "backupkeep", 5, "Amount of numbered backups in backup directory.\n"+
+		"Zero value keeps all backups.")
+
+	flag.Parse()
+
+	if *convert != "" {
+		f, err := os.Open(*convert)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		script, err := editor.ConvertKeyScript(f)
+		if err != nil {
+			log.Fatalf("%s: %v", *convert, err)
+		}
+		os.Stdout.Write(script)
+		return
+	}
+
+	file := *filename
+	if flag.NArg() > 0 {
+		file = flag.Arg(0)
+	}
+
+	if *replay != "" {
+		f, err := os.Open(*replay)
+		if err != nil {
+			log.Fatal(err)
+		}
+		options.Replay, err = editor.ParseKeyScript(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", *replay, err)
+		}
+		input := strings.TrimSuffix(*replay, ".keys") + ".input"
+		if content, err := ioutil.ReadFile(input); err == nil {
+			dir, err := ioutil.TempDir("", "pe-replay")
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			file = filepath.Join(dir, filepath.Base(input))
+			if err := ioutil.WriteFile(file, content, 0644); err != nil {
+				log.Fatal(err)
+			}
+		} else if !os.IsNotExist(err) {
+			log.Fatal(err)
+		}
+	}
+
+	// generate key store
+	if *key.store {
+		for prefix := 0; ; prefix++ {
+			key.filename = fmt.Sprintf("./editor/testdata/%d.keys", prefix)
+			key.text = fmt.Sprintf("./editor/testdata/%d.file", prefix)
+			if _, err := os.Stat(key.filename); os.IsNotExist(err) { // create file if not exists
+				// snapshot of edited file is initial content of result
+				var content []byte
+				if file != "" && file != "-" {
+					if content, err = ioutil.ReadFile(file); err != nil && !os.IsNotExist(err) {
+						log.Fatal(err)
+					}
+					input := fmt.Sprintf("./editor/testdata/%d.input", prefix)
+					if err := ioutil.WriteFile(input, content, 0644); err != nil {
+						log.Fatal(err)
+					}
+				}
+				if err := ioutil.WriteFile(key.filename, nil, 0644); err != nil {
+					log.Fatal(err)
+				}
+				if err := ioutil.WriteFile(key.text, content, 0644); err != nil {
+					log.Fatal(err)
+				}
+				break
+			}
+		}
+		options.Record = key.filename
+		file = key.text
+	}
+
+	// read buffer from stdin, keys are read from terminal or script
+	if file == "-" && !editor.IsTerminal(os.Stdout.Fd()) {
+		options.Stdout = true
+	}
+
+	var script *editor.KeyScript
+	if *scriptFile != "" {
+		f, err := os.Open(*scriptFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		script, err = editor.ParseKeyScript(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", *scriptFile, err)
+		}
+		options.Swap = false
+	}
+
+	e, err := editor.New(options)
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch file {
+	case "":
+	case "-":
+		if err := e.Load(os.Stdin); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		if err := e.Open(file); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if script != nil {
+		if err := e.RunScript(script); err != nil {
+			log.Fatalf("%s: %v", *scriptFile, err)
+		}
+	} else {
+		in, out := os.Stdin, os.Stdout
+		if !editor.IsTerminal(in.Fd()) || !editor.IsTerminal(out.Fd()) {
+			tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+			if err != nil {
+				log.Fatalf("Cannot open terminal: %v", err)
+			}
+			defer tty.Close()
+			in, out = tty, tty
+		}
+
+		if err := e.RunTerminal(in, out); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if options.Stdout {
+		out, err := e.Content()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := os.Stdout.Write(out); err != nil {
+			log.Fatal(err)
+		}
+	}
+}